@@ -3,7 +3,8 @@ package db
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -12,7 +13,8 @@ import (
 func Init(dsn string) *sql.DB {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
+		slog.Error("failed to open database", "err", err)
+		os.Exit(1)
 	}
 
 	db.SetMaxOpenConns(25)
@@ -20,7 +22,8 @@ func Init(dsn string) *sql.DB {
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	if err := db.Ping(); err != nil {
-		log.Fatalf("Database unreachable: %v", err)
+		slog.Error("database unreachable", "err", err)
+		os.Exit(1)
 	}
 
 	return db
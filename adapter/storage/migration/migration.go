@@ -0,0 +1,126 @@
+// Package migration applies the versioned SQL schema embedded in the binary.
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+type migration struct {
+	version int
+	name    string
+	body    string
+}
+
+// Up applies every migration newer than the current schema version, in order.
+// Each applied version is recorded in schema_migrations.
+func Up(db *sql.DB) (int, error) {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := Version(db)
+	if err != nil {
+		return 0, err
+	}
+
+	all, err := load()
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range all {
+		if m.version <= current {
+			continue
+		}
+		for _, stmt := range splitStatements(m.body) {
+			if _, err := db.Exec(stmt); err != nil {
+				return applied, fmt.Errorf("migration %s failed: %w", m.name, err)
+			}
+		}
+		if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			return applied, fmt.Errorf("failed to record migration %s: %w", m.name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Version returns the highest applied migration, or 0 if none were applied.
+func Version(db *sql.DB) (int, error) {
+	var v sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&v); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(v.Int64), nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func Latest() int {
+	all, err := load()
+	if err != nil || len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].version
+}
+
+func load() ([]migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var all []migration
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", e.Name())
+		}
+		body, err := files.ReadFile("sql/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, migration{version: v, name: e.Name(), body: string(body)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].version < all[j].version })
+	return all, nil
+}
+
+// splitStatements breaks a file into statements, since the DSN does not enable multiStatements.
+func splitStatements(body string) []string {
+	var stmts []string
+	var b strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(b.String()), ";"))
+			b.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,8 @@
+-- Store the provider timestamp with every history row so dates are calendar independent
+ALTER TABLE price_history
+    ADD COLUMN time_unix BIGINT NULL AFTER type,
+    ADD INDEX idx_symbol_time_unix (symbol, time_unix);
+
+UPDATE price_history SET time_unix = UNIX_TIMESTAMP(recorded_at) WHERE time_unix IS NULL;
+
+UPDATE prices SET time_unix = UNIX_TIMESTAMP(updated_at) WHERE time_unix IS NULL;
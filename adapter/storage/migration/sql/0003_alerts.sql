@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS alerts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    symbol VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    `condition` VARCHAR(20) NOT NULL,
+    threshold DOUBLE NOT NULL,
+    unit VARCHAR(20) NOT NULL DEFAULT '',
+    window_seconds BIGINT NOT NULL DEFAULT 0,
+    mode VARCHAR(20) NOT NULL DEFAULT 'once',
+    cooldown_seconds BIGINT NOT NULL DEFAULT 0,
+    active TINYINT(1) NOT NULL DEFAULT 1,
+    last_triggered_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_active (active)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS alert_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    alert_id INT NOT NULL,
+    symbol VARCHAR(50) NOT NULL,
+    value DOUBLE NOT NULL,
+    message VARCHAR(255) NOT NULL,
+    triggered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_alert_id (alert_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
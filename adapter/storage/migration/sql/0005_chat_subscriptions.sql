@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS chat_subscriptions (
+    chat_id BIGINT PRIMARY KEY,
+    symbols TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+ALTER TABLE alerts ADD COLUMN chat_id BIGINT NOT NULL DEFAULT 0 AFTER user_id;
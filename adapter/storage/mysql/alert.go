@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+const alertColumns = "id, user_id, chat_id, symbol, kind, `condition`, threshold, unit, window_seconds, mode, cooldown_seconds, active, last_triggered_at, created_at"
+
+func (r *Repository) CreateAlert(ctx context.Context, a *entity.Alert) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO alerts (user_id, chat_id, symbol, kind, `condition`, threshold, unit, window_seconds, mode, cooldown_seconds, active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		a.UserID, a.ChatID, a.Symbol, a.Kind, a.Condition, a.Threshold, a.Unit, a.WindowSeconds, a.Mode, a.CooldownSeconds, a.Active)
+	if err != nil {
+		return fmt.Errorf("repository create alert error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = uint(id)
+	a.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetAlert(ctx context.Context, id uint) (entity.Alert, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+alertColumns+" FROM alerts WHERE id = ?", id)
+	a, err := scanAlert(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, entity.ErrNotFound
+	}
+	return a, err
+}
+
+// ListAlerts returns every alert, or only active ones when activeOnly is set.
+func (r *Repository) ListAlerts(ctx context.Context, activeOnly bool) ([]entity.Alert, error) {
+	query := "SELECT " + alertColumns + " FROM alerts"
+	if activeOnly {
+		query += " WHERE active = 1"
+	}
+	return r.queryAlerts(ctx, query+" ORDER BY id")
+}
+
+func (r *Repository) ListUserAlerts(ctx context.Context, userID uint) ([]entity.Alert, error) {
+	return r.queryAlerts(ctx, "SELECT "+alertColumns+" FROM alerts WHERE user_id = ? ORDER BY id", userID)
+}
+
+func (r *Repository) queryAlerts(ctx context.Context, query string, args ...interface{}) ([]entity.Alert, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository alerts query error: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []entity.Alert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+func (r *Repository) DeleteAlert(ctx context.Context, id uint) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM alerts WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("repository delete alert error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// MarkAlertTriggered stores the trigger time and deactivates one-shot alerts.
+func (r *Repository) MarkAlertTriggered(ctx context.Context, id uint, at time.Time, deactivate bool) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE alerts SET last_triggered_at = ?, active = active AND NOT ? WHERE id = ?", at, deactivate, id)
+	return err
+}
+
+func (r *Repository) SaveAlertEvent(ctx context.Context, e *entity.AlertEvent) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO alert_events (alert_id, symbol, value, message, triggered_at) VALUES (?, ?, ?, ?, ?)",
+		e.AlertID, e.Symbol, e.Value, e.Message, e.TriggeredAt)
+	if err != nil {
+		return fmt.Errorf("repository save alert event error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = uint(id)
+	return nil
+}
+
+func (r *Repository) ListAlertEvents(ctx context.Context, alertID uint, limit int) ([]entity.AlertEvent, error) {
+	query := "SELECT e.id, e.alert_id, e.symbol, COALESCE(a.kind, ''), COALESCE(a.`condition`, ''), COALESCE(a.threshold, 0), e.value, e.message, e.triggered_at " +
+		"FROM alert_events e LEFT JOIN alerts a ON a.id = e.alert_id WHERE e.alert_id = ? ORDER BY e.triggered_at DESC LIMIT ?"
+
+	rows, err := r.db.QueryContext(ctx, query, alertID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository alert events query error: %w", err)
+	}
+	defer rows.Close()
+
+	var events []entity.AlertEvent
+	for rows.Next() {
+		var e entity.AlertEvent
+		if err := rows.Scan(&e.ID, &e.AlertID, &e.Symbol, &e.Kind, &e.Condition, &e.Threshold, &e.Value, &e.Message, &e.TriggeredAt); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlert(s rowScanner) (entity.Alert, error) {
+	var a entity.Alert
+	var last sql.NullTime
+	err := s.Scan(&a.ID, &a.UserID, &a.ChatID, &a.Symbol, &a.Kind, &a.Condition, &a.Threshold, &a.Unit, &a.WindowSeconds,
+		&a.Mode, &a.CooldownSeconds, &a.Active, &last, &a.CreatedAt)
+	if last.Valid {
+		a.LastTriggeredAt = &last.Time
+	}
+	return a, err
+}
@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+const apiKeyColumns = "id, name, prefix, hash, scopes, rate_limit, daily_quota, active, created_at, last_used_at"
+
+func (r *Repository) CreateAPIKey(ctx context.Context, k *entity.APIKey) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO api_keys (name, prefix, hash, scopes, rate_limit, daily_quota, active) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		k.Name, k.Prefix, k.Hash, joinList(k.Scopes), k.RateLimit, k.DailyQuota, k.Active)
+	if err != nil {
+		return fmt.Errorf("repository create api key error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	k.ID = uint(id)
+	k.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, id uint) (entity.APIKey, error) {
+	return r.getAPIKey(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id)
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	return r.getAPIKey(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE hash = ?", hash)
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("repository api keys query error: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []entity.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *Repository) RevokeAPIKey(ctx context.Context, id uint) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE api_keys SET active = 0 WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("repository revoke api key error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// AddAPIKeyUsage adds n requests to the counter of a key for the given day.
+func (r *Repository) AddAPIKeyUsage(ctx context.Context, id uint, day time.Time, n int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO api_key_usage (key_id, day, requests) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE requests = requests + VALUES(requests)",
+		id, day.Format("2006-01-02"), n); err != nil {
+		return fmt.Errorf("repository api key usage error: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) GetAPIKeyUsage(ctx context.Context, id uint, day time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, "SELECT requests FROM api_key_usage WHERE key_id = ? AND day = ?", id, day.Format("2006-01-02")).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// ListAPIKeyUsage returns the daily counters of a key, newest first.
+func (r *Repository) ListAPIKeyUsage(ctx context.Context, id uint, days int) ([]entity.APIKeyUsage, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT DATE_FORMAT(day, '%Y-%m-%d'), requests FROM api_key_usage WHERE key_id = ? ORDER BY day DESC LIMIT ?", id, days)
+	if err != nil {
+		return nil, fmt.Errorf("repository api key usage query error: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []entity.APIKeyUsage
+	for rows.Next() {
+		var u entity.APIKeyUsage
+		if err := rows.Scan(&u.Day, &u.Requests); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+func (r *Repository) getAPIKey(ctx context.Context, query string, arg interface{}) (entity.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, query, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return k, entity.ErrNotFound
+	}
+	return k, err
+}
+
+func scanAPIKey(s rowScanner) (entity.APIKey, error) {
+	var k entity.APIKey
+	var scopes string
+	var lastUsed sql.NullTime
+	err := s.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.RateLimit, &k.DailyQuota, &k.Active, &k.CreatedAt, &lastUsed)
+	k.Scopes = splitList(scopes)
+	if lastUsed.Valid {
+		k.LastUsedAt = &lastUsed.Time
+	}
+	return k, err
+}
@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// HistoryTimes returns the timestamps already recorded for a symbol.
+func (r *Repository) HistoryTimes(ctx context.Context, symbol string) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT time_unix FROM price_history WHERE symbol = ? AND time_unix IS NOT NULL", symbol)
+	if err != nil {
+		return nil, fmt.Errorf("repository history times query error: %w", err)
+	}
+	defer rows.Close()
+
+	var times []int64
+	for rows.Next() {
+		var t int64
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// InsertHistory adds past prices to the history in one statement, without touching the latest prices.
+func (r *Repository) InsertHistory(ctx context.Context, prices []entity.Price) error {
+	if len(prices) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(prices))
+	args := make([]interface{}, 0, 4*len(prices))
+	for i, p := range prices {
+		placeholders[i] = "(?, ?, ?, ?)"
+		args = append(args, p.Symbol, p.Price.String(), p.Type, p.TimeUnix)
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO price_history (symbol, price, type, time_unix) VALUES "+strings.Join(placeholders, ", "), args...)
+	if err != nil {
+		return fmt.Errorf("repository insert history error: %w", err)
+	}
+	return nil
+}
+
+// SetLatest stores p as the latest price of its symbol unless a newer one is already stored.
+// Names and unit are only filled in when missing. time_unix is assigned last so the
+// other columns compare against the stored time.
+func (r *Repository) SetLatest(ctx context.Context, p entity.Price) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO prices (symbol, name_en, name_fa, price, change_value, change_percent, unit, type, date, time, time_unix)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE
+		price = IF(VALUES(time_unix) >= COALESCE(time_unix, 0), VALUES(price), price),
+		change_value = IF(VALUES(time_unix) >= COALESCE(time_unix, 0), VALUES(change_value), change_value),
+		change_percent = IF(VALUES(time_unix) >= COALESCE(time_unix, 0), VALUES(change_percent), change_percent),
+		date = IF(VALUES(time_unix) >= COALESCE(time_unix, 0), VALUES(date), date),
+		time = IF(VALUES(time_unix) >= COALESCE(time_unix, 0), VALUES(time), time),
+		name_en = IF(COALESCE(name_en, '') = '', VALUES(name_en), name_en),
+		name_fa = IF(COALESCE(name_fa, '') = '', VALUES(name_fa), name_fa),
+		unit = IF(COALESCE(unit, '') = '', VALUES(unit), unit),
+		time_unix = GREATEST(COALESCE(time_unix, 0), VALUES(time_unix))`,
+		p.Symbol, p.NameEn, p.NameFa, p.Price.String(), p.ChangeValue.String(), p.ChangePercent, p.Unit, p.Type, p.Date, p.Time, p.TimeUnix)
+	if err != nil {
+		return fmt.Errorf("repository set latest error: %w", err)
+	}
+	return nil
+}
+
+// HistoryPage returns up to limit history rows of a symbol within [from, to] that come after
+// the row (afterTime, afterID), oldest first. Rows are ordered by time and id so that pages
+// can be read one after another without offsets.
+func (r *Repository) HistoryPage(ctx context.Context, symbol string, from, to time.Time, afterTime int64, afterID uint, limit int) ([]entity.Price, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT h.id, h.symbol, h.price, h.type, h.time_unix
+		FROM price_history h
+		WHERE h.symbol = ? AND h.time_unix BETWEEN ? AND ? AND (h.time_unix, h.id) > (?, ?)
+		ORDER BY h.time_unix, h.id
+		LIMIT ?`, symbol, from.Unix(), to.Unix(), afterTime, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository history page query error: %w", err)
+	}
+	defer rows.Close()
+
+	var page []entity.Price
+	for rows.Next() {
+		var p entity.Price
+		if err := rows.Scan(&p.ID, &p.Symbol, &p.Price, &p.Type, &p.TimeUnix); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		page = append(page, p)
+	}
+	return page, rows.Err()
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// ListLatest returns the latest price of every symbol, ordered by symbol. Filtering,
+// sorting and paging happen on the cached quotes.
+func (r *Repository) ListLatest(ctx context.Context) ([]entity.Price, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, COALESCE(date, ''), COALESCE(time, ''), COALESCE(time_unix, 0), symbol, "+
+		"COALESCE(name_en, ''), COALESCE(name_fa, ''), price, COALESCE(change_value, ''), COALESCE(change_percent, 0), "+
+		"COALESCE(unit, ''), type, updated_at FROM prices ORDER BY symbol, id")
+	if err != nil {
+		return nil, fmt.Errorf("repository latest prices query error: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []entity.Price
+	for rows.Next() {
+		var p entity.Price
+		var updated sql.NullTime
+		if err := rows.Scan(&p.ID, &p.Date, &p.Time, &p.TimeUnix, &p.Symbol, &p.NameEn, &p.NameFa,
+			&p.Price, &p.ChangeValue, &p.ChangePercent, &p.Unit, &p.Type, &updated); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		p.UpdatedAt = updated.Time
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+const transactionColumns = "id, portfolio_id, symbol, side, quantity, price, unit, price_irt, price_usd, executed_at, note, created_at"
+
+func (r *Repository) CreatePortfolio(ctx context.Context, p *entity.Portfolio) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO portfolios (user_id, name) VALUES (?, ?)", p.UserID, p.Name)
+	if isDuplicate(err) {
+		return entity.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("repository create portfolio error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = uint(id)
+	p.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetPortfolio(ctx context.Context, id uint) (entity.Portfolio, error) {
+	var p entity.Portfolio
+	err := r.db.QueryRowContext(ctx, "SELECT id, user_id, name, created_at FROM portfolios WHERE id = ?", id).
+		Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, entity.ErrNotFound
+	}
+	return p, err
+}
+
+func (r *Repository) ListPortfolios(ctx context.Context, userID uint) ([]entity.Portfolio, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, name, created_at FROM portfolios WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository portfolios query error: %w", err)
+	}
+	defer rows.Close()
+
+	var portfolios []entity.Portfolio
+	for rows.Next() {
+		var p entity.Portfolio
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Name, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		portfolios = append(portfolios, p)
+	}
+	return portfolios, rows.Err()
+}
+
+// DeletePortfolio removes a portfolio together with its transactions.
+func (r *Repository) DeletePortfolio(ctx context.Context, id uint) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM portfolios WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("repository delete portfolio error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM portfolio_transactions WHERE portfolio_id = ?", id); err != nil {
+		return fmt.Errorf("repository delete portfolio error: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *Repository) CreateTransaction(ctx context.Context, t *entity.PortfolioTransaction) error {
+	res, err := r.db.ExecContext(ctx, `INSERT INTO portfolio_transactions
+		(portfolio_id, symbol, side, quantity, price, unit, price_irt, price_usd, executed_at, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.PortfolioID, t.Symbol, t.Side, t.Quantity, t.Price, t.Unit, t.PriceIRT, t.PriceUSD, t.ExecutedAt, truncate(t.Note, 255))
+	if err != nil {
+		return fmt.Errorf("repository create transaction error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = uint(id)
+	t.CreatedAt = time.Now()
+	return nil
+}
+
+// ListTransactions returns the transactions of a portfolio in execution order.
+func (r *Repository) ListTransactions(ctx context.Context, portfolioID uint) ([]entity.PortfolioTransaction, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+transactionColumns+" FROM portfolio_transactions WHERE portfolio_id = ? ORDER BY executed_at, id", portfolioID)
+	if err != nil {
+		return nil, fmt.Errorf("repository transactions query error: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []entity.PortfolioTransaction
+	for rows.Next() {
+		var t entity.PortfolioTransaction
+		if err := rows.Scan(&t.ID, &t.PortfolioID, &t.Symbol, &t.Side, &t.Quantity, &t.Price, &t.Unit,
+			&t.PriceIRT, &t.PriceUSD, &t.ExecutedAt, &t.Note, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *Repository) DeleteTransaction(ctx context.Context, portfolioID, id uint) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM portfolio_transactions WHERE id = ? AND portfolio_id = ?", id, portfolioID)
+	if err != nil {
+		return fmt.Errorf("repository delete transaction error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/ar-mokhtari/market-tracker/entity"
@@ -18,39 +19,50 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// Upsert ensures data is only added to history if the price has changed.
-func (r *Repository) Upsert(p entity.Price) error {
+// Upsert ensures data is only added to history if the price has changed. The stored unit
+// follows the feed, unless the feed sends none.
+func (r *Repository) Upsert(ctx context.Context, p entity.Price) error {
 	var lastPrice string
 
+	// Fall back to the ingestion time when the provider sends no timestamp
+	if p.TimeUnix == 0 {
+		p.TimeUnix = time.Now().Unix()
+	}
+
 	// Query the most recent price for this specific symbol
-	err := r.db.QueryRow("SELECT price FROM price_history WHERE symbol = ? ORDER BY recorded_at DESC LIMIT 1", p.Symbol).Scan(&lastPrice)
+	err := r.db.QueryRowContext(ctx, "SELECT price FROM price_history WHERE symbol = ? ORDER BY time_unix DESC, id DESC LIMIT 1", p.Symbol).Scan(&lastPrice)
 
 	// If price is identical, skip history insert but update the main prices table
 	if err == nil && lastPrice == p.Price.String() {
-		_, err = r.db.Exec("UPDATE prices SET date=?, time=?, updated_at=CURRENT_TIMESTAMP WHERE symbol=?", p.Date, p.Time, p.Symbol)
+		_, err = r.db.ExecContext(ctx, "UPDATE prices SET date=?, time=?, time_unix=?, change_value=?, change_percent=?, unit=COALESCE(NULLIF(?, ''), unit), updated_at=CURRENT_TIMESTAMP WHERE symbol=?",
+			p.Date, p.Time, p.TimeUnix, p.ChangeValue.String(), p.ChangePercent, p.Unit, p.Symbol)
+		slog.DebugContext(ctx, "price unchanged", "symbol", p.Symbol, "err", err)
 		return err
 	}
 
 	// Otherwise, record the change in both tables
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	_, _ = tx.Exec(`INSERT INTO prices (symbol, name_fa, price, unit, type, date, time)
-		VALUES (?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE price=VALUES(price), date=VALUES(date), time=VALUES(time)`,
-		p.Symbol, p.NameFa, p.Price, p.Unit, p.Type, p.Date, p.Time)
+	_, _ = tx.ExecContext(ctx, `INSERT INTO prices (symbol, name_en, name_fa, price, change_value, change_percent, unit, type, date, time, time_unix)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE price=VALUES(price), change_value=VALUES(change_value),
+		change_percent=VALUES(change_percent), unit=COALESCE(NULLIF(VALUES(unit), ''), unit), date=VALUES(date), time=VALUES(time), time_unix=VALUES(time_unix)`,
+		p.Symbol, p.NameEn, p.NameFa, p.Price, p.ChangeValue.String(), p.ChangePercent, p.Unit, p.Type, p.Date, p.Time, p.TimeUnix)
 
-	_, _ = tx.Exec("INSERT INTO price_history (symbol, price, type) VALUES (?, ?, ?)", p.Symbol, p.Price, p.Type)
+	_, _ = tx.ExecContext(ctx, "INSERT INTO price_history (symbol, price, type, time_unix) VALUES (?, ?, ?, ?)", p.Symbol, p.Price, p.Type, p.TimeUnix)
 
-	return tx.Commit()
+	err = tx.Commit()
+	slog.DebugContext(ctx, "price change recorded", "symbol", p.Symbol, "price", p.Price.String(), "err", err)
+	return err
 }
 
-func (r *Repository) List(pType string) ([]entity.Price, error) {
-	query := `SELECT symbol, name_fa, price, unit, type, date, time
+func (r *Repository) List(ctx context.Context, pType string) ([]entity.Price, error) {
+	query := `SELECT symbol, name_fa, price, unit, type, date, time, COALESCE(time_unix, 0)
 	          FROM prices WHERE type = ?`
 
-	rows, err := r.db.Query(query, pType)
+	rows, err := r.db.QueryContext(ctx, query, pType)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +71,7 @@ func (r *Repository) List(pType string) ([]entity.Price, error) {
 	var prices []entity.Price
 	for rows.Next() {
 		var p entity.Price
-		err := rows.Scan(&p.Symbol, &p.NameFa, &p.Price, &p.Unit, &p.Type, &p.Date, &p.Time)
+		err := rows.Scan(&p.Symbol, &p.NameFa, &p.Price, &p.Unit, &p.Type, &p.Date, &p.Time, &p.TimeUnix)
 		if err != nil {
 			return nil, err
 		}
@@ -68,32 +80,78 @@ func (r *Repository) List(pType string) ([]entity.Price, error) {
 	return prices, nil
 }
 
-func (r *Repository) GetHistory(symbol string, limit int) ([]entity.Price, error) {
-	query := `SELECT symbol, price, recorded_at
-	          FROM price_history
-	          WHERE symbol = ?
-	          ORDER BY recorded_at DESC
+func (r *Repository) GetHistory(ctx context.Context, symbol string, limit int) ([]entity.Price, error) {
+	query := `SELECT h.symbol, h.price, h.type, COALESCE(p.unit, ''), h.time_unix
+	          FROM price_history h
+	          LEFT JOIN prices p ON p.symbol = h.symbol AND p.type = h.type
+	          WHERE h.symbol = ?
+	          ORDER BY h.time_unix DESC
 	          LIMIT ?`
 
-	rows, err := r.db.Query(query, symbol, limit)
+	rows, err := r.db.QueryContext(ctx, query, symbol, limit)
 	if err != nil {
 		return nil, fmt.Errorf("repository history query error: %w", err)
 	}
 	defer rows.Close()
 
+	return scanHistory(rows)
+}
+
+// GetHistoryRange returns the history of a symbol between two unix timestamps, newest first.
+func (r *Repository) GetHistoryRange(ctx context.Context, symbol string, from, to time.Time, limit int) ([]entity.Price, error) {
+	query := `SELECT h.symbol, h.price, h.type, COALESCE(p.unit, ''), h.time_unix
+	          FROM price_history h
+	          LEFT JOIN prices p ON p.symbol = h.symbol AND p.type = h.type
+	          WHERE h.symbol = ? AND h.time_unix BETWEEN ? AND ?
+	          ORDER BY h.time_unix DESC
+	          LIMIT ?`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, from.Unix(), to.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository history range query error: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHistory(rows)
+}
+
+// PriceBefore returns the last recorded price of a symbol before at, or entity.ErrNotFound
+// when the symbol has no earlier history.
+func (r *Repository) PriceBefore(ctx context.Context, symbol string, at time.Time) (entity.Price, error) {
+	query := `SELECT h.symbol, h.price, h.type, COALESCE(p.unit, ''), h.time_unix
+	          FROM price_history h
+	          LEFT JOIN prices p ON p.symbol = h.symbol AND p.type = h.type
+	          WHERE h.symbol = ? AND h.time_unix < ?
+	          ORDER BY h.time_unix DESC, h.id DESC
+	          LIMIT 1`
+
+	rows, err := r.db.QueryContext(ctx, query, symbol, at.Unix())
+	if err != nil {
+		return entity.Price{}, fmt.Errorf("repository price before query error: %w", err)
+	}
+	defer rows.Close()
+
+	history, err := scanHistory(rows)
+	if err != nil {
+		return entity.Price{}, err
+	}
+	if len(history) == 0 {
+		return entity.Price{}, entity.ErrNotFound
+	}
+	return history[0], nil
+}
+
+// scanHistory reads rows of (symbol, price, type, unit, time_unix).
+func scanHistory(rows *sql.Rows) ([]entity.Price, error) {
 	var history []entity.Price
 	for rows.Next() {
 		var p entity.Price
-		// Mapping recorded_at to Date for simplicity in this DTO
-		var recordedAt time.Time
-		if err := rows.Scan(&p.Symbol, &p.Price, &recordedAt); err != nil {
+		if err := rows.Scan(&p.Symbol, &p.Price, &p.Type, &p.Unit, &p.TimeUnix); err != nil {
 			return nil, fmt.Errorf("repository scan error: %w", err)
 		}
-		p.Date = recordedAt.Format("2006-01-02")
-		p.Time = recordedAt.Format("15:04:05")
 		history = append(history, p)
 	}
-	return history, nil
+	return history, rows.Err()
 }
 
 func (r *Repository) GetAllPrices(ctx context.Context, priceType string) ([]entity.Price, error) {
@@ -102,10 +160,10 @@ func (r *Repository) GetAllPrices(ctx context.Context, priceType string) ([]enti
 	var args []interface{}
 
 	if priceType != "" {
-		query = "SELECT date, time, symbol, price, type FROM prices WHERE type = ? ORDER BY created_at DESC"
+		query = "SELECT date, time, COALESCE(time_unix, 0), symbol, COALESCE(name_en, ''), COALESCE(name_fa, ''), price, COALESCE(change_value, ''), COALESCE(change_percent, 0), unit, type FROM prices WHERE type = ? ORDER BY created_at DESC"
 		args = append(args, priceType)
 	} else {
-		query = "SELECT date, time, symbol, price, type FROM prices ORDER BY created_at DESC"
+		query = "SELECT date, time, COALESCE(time_unix, 0), symbol, COALESCE(name_en, ''), COALESCE(name_fa, ''), price, COALESCE(change_value, ''), COALESCE(change_percent, 0), unit, type FROM prices ORDER BY created_at DESC"
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -117,7 +175,7 @@ func (r *Repository) GetAllPrices(ctx context.Context, priceType string) ([]enti
 	for rows.Next() {
 		var p entity.Price
 		// Make sure these fields match your entity.Price struct fields
-		err := rows.Scan(&p.Date, &p.Time, &p.Symbol, &p.Price, &p.Type)
+		err := rows.Scan(&p.Date, &p.Time, &p.TimeUnix, &p.Symbol, &p.NameEn, &p.NameFa, &p.Price, &p.ChangeValue, &p.ChangePercent, &p.Unit, &p.Type)
 		if err != nil {
 			return nil, err
 		}
@@ -130,3 +188,24 @@ func (r *Repository) GetAllPrices(ctx context.Context, priceType string) ([]enti
 
 	return prices, nil
 }
+
+// GetPricesAt returns, for every symbol, the last recorded price at or before the given time.
+// Of rows sharing the last timestamp, the one inserted last is returned, so every symbol
+// appears once.
+func (r *Repository) GetPricesAt(ctx context.Context, at time.Time) ([]entity.Price, error) {
+	query := `SELECT h.symbol, h.price, h.type, COALESCE(p.unit, ''), h.time_unix
+	          FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY symbol ORDER BY time_unix DESC, id DESC) AS n
+	                FROM price_history
+	                WHERE time_unix <= ?) last
+	          JOIN price_history h ON h.id = last.id
+	          LEFT JOIN prices p ON p.symbol = h.symbol AND p.type = h.type
+	          WHERE last.n = 1`
+
+	rows, err := r.db.QueryContext(ctx, query, at.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("repository prices at query error: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHistory(rows)
+}
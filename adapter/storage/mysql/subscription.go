@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// SaveChatSubscription creates or replaces the subscription of a chat.
+func (r *Repository) SaveChatSubscription(ctx context.Context, s entity.ChatSubscription) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO chat_subscriptions (chat_id, symbols) VALUES (?, ?) ON DUPLICATE KEY UPDATE symbols = VALUES(symbols)",
+		s.ChatID, joinList(s.Symbols))
+	if err != nil {
+		return fmt.Errorf("repository save subscription error: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteChatSubscription(ctx context.Context, chatID int64) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM chat_subscriptions WHERE chat_id = ?", chatID)
+	if err != nil {
+		return fmt.Errorf("repository delete subscription error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository) GetChatSubscription(ctx context.Context, chatID int64) (entity.ChatSubscription, error) {
+	subs, err := r.querySubscriptions(ctx, "SELECT chat_id, COALESCE(symbols, ''), created_at FROM chat_subscriptions WHERE chat_id = ?", chatID)
+	if err != nil {
+		return entity.ChatSubscription{}, err
+	}
+	if len(subs) == 0 {
+		return entity.ChatSubscription{}, entity.ErrNotFound
+	}
+	return subs[0], nil
+}
+
+func (r *Repository) ListChatSubscriptions(ctx context.Context) ([]entity.ChatSubscription, error) {
+	return r.querySubscriptions(ctx, "SELECT chat_id, COALESCE(symbols, ''), created_at FROM chat_subscriptions")
+}
+
+func (r *Repository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]entity.ChatSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository subscriptions query error: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []entity.ChatSubscription
+	for rows.Next() {
+		var s entity.ChatSubscription
+		var symbols string
+		if err := rows.Scan(&s.ChatID, &symbols, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		s.Symbols = splitList(symbols)
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedRepository records a span around every price repository call.
+// The other repository methods are promoted from the embedded Repository unchanged.
+type TracedRepository struct {
+	*Repository
+}
+
+func NewTracedRepository(db *sql.DB) *TracedRepository {
+	return &TracedRepository{Repository: NewRepository(db)}
+}
+
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "Repo."+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, attribute.String("db.system", "mysql"))...))
+}
+
+// endSpan records the number of rows returned and the error, if any.
+func endSpan(span trace.Span, rows []entity.Price, err error) {
+	span.SetAttributes(attribute.Int("db.rows", len(rows)))
+	tracing.End(span, err)
+}
+
+func (t *TracedRepository) Upsert(ctx context.Context, p entity.Price) error {
+	ctx, span := startSpan(ctx, "Upsert", attribute.String("symbol", p.Symbol))
+	err := t.Repository.Upsert(ctx, p)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedRepository) List(ctx context.Context, pType string) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "List", attribute.String("type", pType))
+	prices, err := t.Repository.List(ctx, pType)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) GetHistory(ctx context.Context, symbol string, limit int) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "GetHistory", attribute.String("symbol", symbol), attribute.Int("limit", limit))
+	prices, err := t.Repository.GetHistory(ctx, symbol, limit)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) GetHistoryRange(ctx context.Context, symbol string, from, to time.Time, limit int) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "GetHistoryRange", attribute.String("symbol", symbol),
+		attribute.Int64("from", from.Unix()), attribute.Int64("to", to.Unix()), attribute.Int("limit", limit))
+	prices, err := t.Repository.GetHistoryRange(ctx, symbol, from, to, limit)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) GetAllPrices(ctx context.Context, priceType string) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "GetAllPrices", attribute.String("type", priceType))
+	prices, err := t.Repository.GetAllPrices(ctx, priceType)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) PriceBefore(ctx context.Context, symbol string, at time.Time) (entity.Price, error) {
+	ctx, span := startSpan(ctx, "PriceBefore", attribute.String("symbol", symbol), attribute.Int64("at", at.Unix()))
+	p, err := t.Repository.PriceBefore(ctx, symbol, at)
+	if errors.Is(err, entity.ErrNotFound) {
+		// A symbol without earlier history is not a failure
+		endSpan(span, nil, nil)
+	} else {
+		endSpan(span, []entity.Price{p}, err)
+	}
+	return p, err
+}
+
+func (t *TracedRepository) GetPricesAt(ctx context.Context, at time.Time) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "GetPricesAt", attribute.Int64("at", at.Unix()))
+	prices, err := t.Repository.GetPricesAt(ctx, at)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) HistoryTimes(ctx context.Context, symbol string) ([]int64, error) {
+	ctx, span := startSpan(ctx, "HistoryTimes", attribute.String("symbol", symbol))
+	times, err := t.Repository.HistoryTimes(ctx, symbol)
+	span.SetAttributes(attribute.Int("db.rows", len(times)))
+	tracing.End(span, err)
+	return times, err
+}
+
+func (t *TracedRepository) InsertHistory(ctx context.Context, prices []entity.Price) error {
+	ctx, span := startSpan(ctx, "InsertHistory", attribute.Int("db.rows", len(prices)))
+	err := t.Repository.InsertHistory(ctx, prices)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedRepository) SetLatest(ctx context.Context, p entity.Price) error {
+	ctx, span := startSpan(ctx, "SetLatest", attribute.String("symbol", p.Symbol))
+	err := t.Repository.SetLatest(ctx, p)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *TracedRepository) HistoryPage(ctx context.Context, symbol string, from, to time.Time, afterTime int64, afterID uint, limit int) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "HistoryPage", attribute.String("symbol", symbol),
+		attribute.Int64("from", from.Unix()), attribute.Int64("to", to.Unix()), attribute.Int("limit", limit))
+	prices, err := t.Repository.HistoryPage(ctx, symbol, from, to, afterTime, afterID, limit)
+	endSpan(span, prices, err)
+	return prices, err
+}
+
+func (t *TracedRepository) ListLatest(ctx context.Context) ([]entity.Price, error) {
+	ctx, span := startSpan(ctx, "ListLatest")
+	prices, err := t.Repository.ListLatest(ctx)
+	endSpan(span, prices, err)
+	return prices, err
+}
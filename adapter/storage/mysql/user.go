@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	driver "github.com/go-sql-driver/mysql"
+)
+
+const userColumns = "id, email, name, password_hash, created_at"
+
+// errDuplicateEntry is the MySQL error number for a unique key violation.
+const errDuplicateEntry = 1062
+
+func (r *Repository) CreateUser(ctx context.Context, u *entity.User) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO users (email, name, password_hash) VALUES (?, ?, ?)", u.Email, u.Name, u.PasswordHash)
+	if isDuplicate(err) {
+		return entity.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("repository create user error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = uint(id)
+	u.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetUser(ctx context.Context, id uint) (entity.User, error) {
+	return r.getUser(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id)
+}
+
+func (r *Repository) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	return r.getUser(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?", email)
+}
+
+func (r *Repository) getUser(ctx context.Context, query string, arg interface{}) (entity.User, error) {
+	var u entity.User
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, entity.ErrNotFound
+	}
+	return u, err
+}
+
+func (r *Repository) CreateRefreshToken(ctx context.Context, t *entity.RefreshToken) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO refresh_tokens (user_id, hash, expires_at) VALUES (?, ?, ?)", t.UserID, t.Hash, t.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("repository create refresh token error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	t.ID = uint(id)
+	t.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetRefreshToken(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	var t entity.RefreshToken
+	var revoked sql.NullTime
+	err := r.db.QueryRowContext(ctx, "SELECT id, user_id, hash, expires_at, revoked_at, created_at FROM refresh_tokens WHERE hash = ?", hash).
+		Scan(&t.ID, &t.UserID, &t.Hash, &t.ExpiresAt, &revoked, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, entity.ErrNotFound
+	}
+	if revoked.Valid {
+		t.RevokedAt = &revoked.Time
+	}
+	return t, err
+}
+
+// RevokeRefreshToken marks one token as used. It returns ErrNotFound when it was already revoked,
+// so concurrent refreshes with the same token cannot both succeed.
+func (r *Repository) RevokeRefreshToken(ctx context.Context, id uint) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("repository revoke refresh token error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens logs a user out of every session.
+func (r *Repository) RevokeUserRefreshTokens(ctx context.Context, userID uint) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL", userID)
+	return err
+}
+
+func isDuplicate(err error) bool {
+	var me *driver.MySQLError
+	return errors.As(err, &me) && me.Number == errDuplicateEntry
+}
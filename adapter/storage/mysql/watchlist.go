@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+const watchlistColumns = "id, user_id, name, symbols, created_at, updated_at"
+
+func (r *Repository) CreateWatchlist(ctx context.Context, wl *entity.Watchlist) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO watchlists (user_id, name, symbols) VALUES (?, ?, ?)",
+		wl.UserID, wl.Name, joinList(wl.Symbols))
+	if isDuplicate(err) {
+		return entity.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("repository create watchlist error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	wl.ID = uint(id)
+	wl.CreatedAt = time.Now()
+	wl.UpdatedAt = wl.CreatedAt
+	return nil
+}
+
+func (r *Repository) GetWatchlist(ctx context.Context, id uint) (entity.Watchlist, error) {
+	wl, err := scanWatchlist(r.db.QueryRowContext(ctx, "SELECT "+watchlistColumns+" FROM watchlists WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return wl, entity.ErrNotFound
+	}
+	return wl, err
+}
+
+func (r *Repository) ListWatchlists(ctx context.Context, userID uint) ([]entity.Watchlist, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+watchlistColumns+" FROM watchlists WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository watchlists query error: %w", err)
+	}
+	defer rows.Close()
+
+	var lists []entity.Watchlist
+	for rows.Next() {
+		wl, err := scanWatchlist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		lists = append(lists, wl)
+	}
+	return lists, rows.Err()
+}
+
+func (r *Repository) UpdateWatchlist(ctx context.Context, wl *entity.Watchlist) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE watchlists SET name = ?, symbols = ? WHERE id = ?", wl.Name, joinList(wl.Symbols), wl.ID)
+	if isDuplicate(err) {
+		return entity.ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("repository update watchlist error: %w", err)
+	}
+	wl.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) DeleteWatchlist(ctx context.Context, id uint) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM watchlists WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("repository delete watchlist error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func scanWatchlist(s rowScanner) (entity.Watchlist, error) {
+	var wl entity.Watchlist
+	var symbols string
+	err := s.Scan(&wl.ID, &wl.UserID, &wl.Name, &symbols, &wl.CreatedAt, &wl.UpdatedAt)
+	wl.Symbols = splitList(symbols)
+	return wl, err
+}
@@ -0,0 +1,193 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+const webhookColumns = "id, url, secret, events, COALESCE(symbols, ''), types, COALESCE(alert_ids, ''), active, created_at"
+
+const deliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt_at, last_error, response_code, created_at, delivered_at"
+
+func (r *Repository) CreateWebhook(ctx context.Context, wh *entity.Webhook) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO webhooks (url, secret, events, symbols, types, alert_ids, active) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		wh.URL, wh.Secret, joinList(wh.Events), joinList(wh.Symbols), joinList(wh.Types), joinIDs(wh.AlertIDs), wh.Active)
+	if err != nil {
+		return fmt.Errorf("repository create webhook error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	wh.ID = uint(id)
+	wh.CreatedAt = time.Now()
+	return nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id uint) (entity.Webhook, error) {
+	wh, err := scanWebhook(r.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return wh, entity.ErrNotFound
+	}
+	return wh, err
+}
+
+// ListWebhooks returns every webhook, or only active ones when activeOnly is set.
+func (r *Repository) ListWebhooks(ctx context.Context, activeOnly bool) ([]entity.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks"
+	if activeOnly {
+		query += " WHERE active = 1"
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("repository webhooks query error: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []entity.Webhook
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		hooks = append(hooks, wh)
+	}
+	return hooks, rows.Err()
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, id uint) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("repository delete webhook error: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return entity.ErrNotFound
+	}
+	_, err = r.db.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ? AND status = ?", id, entity.DeliveryPending)
+	return err
+}
+
+func (r *Repository) EnqueueDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at) VALUES (?, ?, ?, ?, ?)",
+		d.WebhookID, d.Event, d.Payload, d.Status, d.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("repository enqueue delivery error: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	d.ID = uint(id)
+	return nil
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is due, oldest first.
+func (r *Repository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT ?",
+		entity.DeliveryPending, now, limit)
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first, optionally filtered by status.
+func (r *Repository) ListDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]entity.WebhookDelivery, error) {
+	if status != "" {
+		return r.queryDeliveries(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? AND status = ? ORDER BY id DESC LIMIT ?",
+			webhookID, status, limit)
+	}
+	return r.queryDeliveries(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?",
+		webhookID, limit)
+}
+
+func (r *Repository) GetDelivery(ctx context.Context, id uint) (entity.WebhookDelivery, error) {
+	d, err := scanDelivery(r.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return d, entity.ErrNotFound
+	}
+	return d, err
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt.
+func (r *Repository) UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, response_code = ?, delivered_at = ?
+		WHERE id = ?`,
+		d.Status, d.Attempts, d.NextAttemptAt, truncate(d.LastError, 1024), d.ResponseCode, d.DeliveredAt, d.ID)
+	return err
+}
+
+func (r *Repository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]entity.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository deliveries query error: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository scan error: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanWebhook(s rowScanner) (entity.Webhook, error) {
+	var wh entity.Webhook
+	var events, symbols, types, alertIDs string
+	err := s.Scan(&wh.ID, &wh.URL, &wh.Secret, &events, &symbols, &types, &alertIDs, &wh.Active, &wh.CreatedAt)
+	wh.Events, wh.Symbols, wh.Types = splitList(events), splitList(symbols), splitList(types)
+	for _, v := range splitList(alertIDs) {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			wh.AlertIDs = append(wh.AlertIDs, uint(id))
+		}
+	}
+	return wh, err
+}
+
+func scanDelivery(s rowScanner) (entity.WebhookDelivery, error) {
+	var d entity.WebhookDelivery
+	var delivered sql.NullTime
+	err := s.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastError, &d.ResponseCode, &d.CreatedAt, &delivered)
+	if delivered.Valid {
+		d.DeliveredAt = &delivered.Time
+	}
+	return d, err
+}
+
+// joinList stores small string sets as comma separated values.
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+func joinIDs(ids []uint) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return joinList(parts)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
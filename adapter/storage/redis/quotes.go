@@ -0,0 +1,83 @@
+// Package redis provides the Redis implementation of the shared quote cache.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// quotesKey holds the latest prices as one JSON array; they are always read and replaced together.
+const quotesKey = "market-tracker:quotes"
+
+const defaultPort = "6379"
+
+type QuoteStore struct {
+	client *goredis.Client
+}
+
+// NewQuoteStore connects lazily to the Redis server at host, which may omit the port.
+func NewQuoteStore(host, password string, db int) *QuoteStore {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, defaultPort)
+	}
+	return &QuoteStore{client: goredis.NewClient(&goredis.Options{
+		Addr:         host,
+		Password:     password,
+		DB:           db,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  time.Second,
+		WriteTimeout: time.Second,
+	})}
+}
+
+func (s *QuoteStore) LoadQuotes(ctx context.Context) ([]entity.Price, error) {
+	raw, err := s.client.Get(ctx, quotesKey).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis quotes read error: %w", err)
+	}
+	var prices []entity.Price
+	if err := json.Unmarshal(raw, &prices); err != nil {
+		return nil, fmt.Errorf("redis quotes decode error: %w", err)
+	}
+	if prices == nil {
+		prices = []entity.Price{}
+	}
+	return prices, nil
+}
+
+func (s *QuoteStore) SaveQuotes(ctx context.Context, prices []entity.Price, ttl time.Duration) error {
+	raw, err := json.Marshal(prices)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Set(ctx, quotesKey, raw, ttl).Err(); err != nil {
+		return fmt.Errorf("redis quotes write error: %w", err)
+	}
+	return nil
+}
+
+// Ping checks the connection and returns the server version.
+func (s *QuoteStore) Ping(ctx context.Context) (string, error) {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return "", err
+	}
+	info, err := s.client.InfoMap(ctx, "server").Result()
+	if err != nil {
+		return "", err
+	}
+	return info["Server"]["redis_version"], nil
+}
+
+func (s *QuoteStore) Close() error {
+	return s.client.Close()
+}
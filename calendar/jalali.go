@@ -0,0 +1,237 @@
+// Package calendar converts between the Jalali (Solar Hijri) and Gregorian calendars.
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embed zone data so Asia/Tehran resolves in minimal images
+)
+
+type Calendar string
+
+const (
+	Jalali    Calendar = "jalali"
+	Gregorian Calendar = "gregorian"
+)
+
+var tehran = loadTehran()
+
+func loadTehran() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		return time.FixedZone("IRST", 3*3600+1800)
+	}
+	return loc
+}
+
+// Tehran returns the location provider dates are expressed in.
+func Tehran() *time.Location {
+	return tehran
+}
+
+// ParseCalendar validates a ?calendar= value, defaulting to Jalali.
+func ParseCalendar(s string) (Calendar, bool) {
+	switch Calendar(strings.ToLower(strings.TrimSpace(s))) {
+	case "", Jalali, "shamsi", "fa":
+		return Jalali, true
+	case Gregorian, "miladi", "en":
+		return Gregorian, true
+	}
+	return "", false
+}
+
+// ToJalali returns the Jalali date of t in Tehran time.
+func ToJalali(t time.Time) (year, month, day int) {
+	t = t.In(tehran)
+	return d2j(g2d(t.Year(), int(t.Month()), t.Day()))
+}
+
+// FromJalali returns midnight of the given Jalali date in Tehran time.
+func FromJalali(year, month, day int) (time.Time, error) {
+	if month < 1 || month > 12 || day < 1 || day > monthLength(year, month) {
+		return time.Time{}, fmt.Errorf("invalid jalali date %d/%02d/%02d", year, month, day)
+	}
+	if _, err := jalCal(year); err != nil {
+		return time.Time{}, err
+	}
+	gy, gm, gd := d2g(j2d(year, month, day))
+	return time.Date(gy, time.Month(gm), gd, 0, 0, 0, 0, tehran), nil
+}
+
+// FormatDate renders the date part of t as 1404/09/29 or 2025-12-20.
+func FormatDate(t time.Time, c Calendar) string {
+	if c == Gregorian {
+		return t.In(tehran).Format("2006-01-02")
+	}
+	y, m, d := ToJalali(t)
+	return fmt.Sprintf("%04d/%02d/%02d", y, m, d)
+}
+
+// FormatTime renders the clock part of t in Tehran time.
+func FormatTime(t time.Time) string {
+	return t.In(tehran).Format("15:04")
+}
+
+// ParseTimestamp accepts unix seconds, an RFC 3339 timestamp, or a Jalali or Gregorian date
+// with an optional clock as accepted by ParseDate.
+func ParseTimestamp(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return ParseDate(v)
+}
+
+// ParseDate parses a date in either calendar, e.g. 1404/09/29 or 2025-12-20.
+// Years before 1700 are taken as Jalali. An optional HH:MM[:SS] may follow after a space.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	datePart, clock, _ := strings.Cut(s, " ")
+
+	parts := strings.FieldsFunc(datePart, func(r rune) bool { return r == '/' || r == '-' })
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid date: %q", s)
+	}
+	var ymd [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date: %q", s)
+		}
+		ymd[i] = n
+	}
+
+	var day time.Time
+	if ymd[0] < 1700 {
+		t, err := FromJalali(ymd[0], ymd[1], ymd[2])
+		if err != nil {
+			return time.Time{}, err
+		}
+		day = t
+	} else {
+		day = time.Date(ymd[0], time.Month(ymd[1]), ymd[2], 0, 0, 0, 0, tehran)
+		if day.Month() != time.Month(ymd[1]) || day.Day() != ymd[2] {
+			return time.Time{}, fmt.Errorf("invalid gregorian date: %q", s)
+		}
+	}
+
+	if clock == "" {
+		return day, nil
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if c, err := time.Parse(layout, clock); err == nil {
+			return day.Add(time.Duration(c.Hour())*time.Hour + time.Duration(c.Minute())*time.Minute + time.Duration(c.Second())*time.Second), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", clock)
+}
+
+func monthLength(year, month int) int {
+	switch {
+	case month <= 6:
+		return 31
+	case month <= 11:
+		return 30
+	}
+	if r, err := jalCal(year); err == nil && r.leap == 0 {
+		return 30
+	}
+	return 29
+}
+
+// The algorithms below follow the jalaali-js reference implementation.
+
+var breaks = []int{-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178}
+
+var errOutOfRange = errors.New("jalali year out of supported range")
+
+type jalCalResult struct {
+	leap  int // 0 when the year is leap
+	gy    int // Gregorian year of the start of the Jalali year
+	march int // Day in March of Farvardin 1st
+}
+
+func jalCal(jy int) (jalCalResult, error) {
+	bl := len(breaks)
+	gy := jy + 621
+	leapJ := -14
+	jp := breaks[0]
+	if jy < jp || jy >= breaks[bl-1] {
+		return jalCalResult{}, errOutOfRange
+	}
+
+	var jump int
+	for i := 1; i < bl; i++ {
+		jm := breaks[i]
+		jump = jm - jp
+		if jy < jm {
+			break
+		}
+		leapJ += jump/33*8 + jump%33/4
+		jp = jm
+	}
+	n := jy - jp
+
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march := 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	leap := ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+	return jalCalResult{leap: leap, gy: gy, march: march}, nil
+}
+
+func j2d(jy, jm, jd int) int {
+	r, _ := jalCal(jy)
+	return g2d(r.gy, 3, r.march) + (jm-1)*31 - jm/7*(jm-7) + jd - 1
+}
+
+func d2j(jdn int) (jy, jm, jd int) {
+	gy, _, _ := d2g(jdn)
+	jy = gy - 621
+	r, _ := jalCal(jy)
+	k := jdn - g2d(gy, 3, r.march)
+	if k >= 0 {
+		if k <= 185 {
+			return jy, 1 + k/31, k%31 + 1
+		}
+		k -= 186
+	} else {
+		jy--
+		k += 179
+		if r.leap == 1 {
+			k++
+		}
+	}
+	return jy, 7 + k/30, k%30 + 1
+}
+
+func g2d(gy, gm, gd int) int {
+	d := (gy+(gm-8)/6+100100)*1461/4 + (153*((gm+9)%12)+2)/5 + gd - 34840408
+	return d - (gy+100100+(gm-8)/6)/100*3/4 + 752
+}
+
+func d2g(jdn int) (gy, gm, gd int) {
+	j := 4*jdn + 139361631
+	j = j + (4*jdn+183187720)/146097*3/4*4 - 3908
+	i := j%1461/4*5 + 308
+	gd = i%153/5 + 1
+	gm = i/153%12 + 1
+	gy = j/1461 - 100100 + (8-gm)/6
+	return gy, gm, gd
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/ar-mokhtari/market-tracker/adapter/storage"
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/migration"
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/mysql"
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	config "github.com/ar-mokhtari/market-tracker/config"
+	delivery "github.com/ar-mokhtari/market-tracker/delivery/http"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/export"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+const usage = `usage: market-tracker [command] [arguments]
+
+commands:
+  serve                  start the HTTP server and the fetcher (default)
+  migrate [status]       apply pending database migrations, or show the schema version
+  fetch-once             fetch and store the latest prices once
+  import [flags] <file>  load historical prices from BrsApi JSON, CSV or an export
+                         [--format brsapi|csv|export] [--type gold] [--dry-run]
+  export [flags]         write the price history or candles as CSV, XLSX or Parquet
+                         --symbols USD,EUR --from 1404/01/01 --to 2025-06-30 --out history.csv
+                         [--format csv|xlsx|parquet] [--kind candles --interval 1d] [--names] [--jalali]
+  backfill [flags] <path>...
+                         import every file, or the .json and .csv files of directories
+  apikey create [flags]  create an API key
+                         --name NAME [--scopes read,stream] [--rate 60] [--quota 0]
+  doctor                 check the configuration, database, migrations, provider and cache
+`
+
+// usageError is a command line mistake; main prints it with the usage.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// runCommand executes a subcommand; without one the server is started. help and doctor
+// run from main before the configuration is loaded.
+func runCommand(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return serve(cfg, nil)
+	}
+	name, args := args[0], args[1:]
+	switch name {
+	case "serve":
+		return serve(cfg, args)
+	case "migrate":
+		return migrate(cfg, args)
+	case "fetch-once":
+		return fetchOnce(cfg, args)
+	case "import":
+		return importFile(cfg, args)
+	case "export":
+		return exportHistory(cfg, args)
+	case "backfill":
+		return backfill(cfg, args)
+	case "apikey":
+		if len(args) == 0 || args[0] != "create" {
+			return usageError("unknown apikey command, use apikey create")
+		}
+		return createAPIKey(cfg, args[1:])
+	}
+	return usageError(fmt.Sprintf("unknown command %q", name))
+}
+
+// openDB connects to the database and, when migrate is set, applies pending migrations
+// like the server does on startup. It exits when the database is unreachable.
+func openDB(cfg *config.Config, migrate bool) *sql.DB {
+	database := db.Init(cfg.DSN())
+	if !migrate {
+		return database
+	}
+	if n, err := migration.Up(database); err != nil {
+		slog.Error("migration failed", "err", err)
+		os.Exit(1)
+	} else if n > 0 {
+		slog.Info("applied database migrations", "count", n)
+	}
+	return database
+}
+
+func migrate(cfg *config.Config, args []string) error {
+	database := openDB(cfg, false)
+	defer database.Close()
+
+	if len(args) == 1 && args[0] == "status" {
+		current, err := migration.Version(database)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("schema version %d, latest %d\n", current, migration.Latest())
+		return nil
+	}
+	if len(args) > 0 {
+		return usageError("migrate takes no arguments other than status")
+	}
+
+	n, err := migration.Up(database)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("applied %d migrations, schema version %d\n", n, migration.Latest())
+	return nil
+}
+
+func fetchOnce(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return usageError("fetch-once takes no arguments")
+	}
+	database := openDB(cfg, true)
+	defer database.Close()
+
+	uc := delivery.NewPriceUseCase(database, cfg)
+	if err := uc.FetchOnce(context.Background()); err != nil {
+		return err
+	}
+	fmt.Printf("fetched prices from %s\n", cfg.Provider.BaseURL)
+	return nil
+}
+
+func importFile(cfg *config.Config, args []string) error {
+	return importFiles(cfg, "import", args, false)
+}
+
+func backfill(cfg *config.Config, args []string) error {
+	return importFiles(cfg, "backfill", args, true)
+}
+
+// importFiles imports files one by one with progress on standard error. With dirs
+// set, directories expand to the .json and .csv files they contain.
+func importFiles(cfg *config.Config, name string, args []string, dirs bool) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	format := fs.String("format", "", "brsapi, csv or export, detected when empty")
+	priceType := fs.String("type", "", "type of new symbols in files without a type column, e.g. gold")
+	dryRun := fs.Bool("dry-run", false, "validate and count without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 || (!dirs && len(paths) > 1) {
+		return usageError(name + " needs a file")
+	}
+	files, err := importPaths(paths, dirs)
+	if err != nil {
+		return err
+	}
+
+	database := openDB(cfg, true)
+	defer database.Close()
+	uc := delivery.NewPriceUseCase(database, cfg)
+
+	var total usecase.ImportProgress
+	for i, path := range files {
+		label := fmt.Sprintf("[%d/%d] %s", i+1, len(files), path)
+		result, err := importOne(uc, path, label, usecase.ImportOptions{Format: *format, Type: *priceType, DryRun: *dryRun})
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, e := range result.Errors {
+			fmt.Fprintf(os.Stderr, "  %s\n", e)
+		}
+		if result.Invalid > len(result.Errors) {
+			fmt.Fprintf(os.Stderr, "  and %d more invalid rows\n", result.Invalid-len(result.Errors))
+		}
+		total.Rows += result.Rows
+		total.Imported += result.Imported
+		total.Duplicates += result.Duplicates
+		total.Invalid += result.Invalid
+	}
+
+	verb := "imported"
+	if *dryRun {
+		verb = "would import"
+	}
+	fmt.Printf("%s %d of %d rows from %d files, %d duplicates, %d invalid\n",
+		verb, total.Imported, total.Rows, len(files), total.Duplicates, total.Invalid)
+	return nil
+}
+
+func importOne(uc *usecase.PriceUseCase, path, label string, opts usecase.ImportOptions) (usecase.ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return usecase.ImportResult{}, err
+	}
+	defer f.Close()
+
+	opts.Name = path
+	opts.Progress = func(p usecase.ImportProgress) {
+		fmt.Fprintf(os.Stderr, "\r%s: %d rows, %d new, %d duplicates, %d invalid", label, p.Rows, p.Imported, p.Duplicates, p.Invalid)
+	}
+	result, err := uc.Import(context.Background(), f, opts)
+	fmt.Fprintln(os.Stderr)
+	return result, err
+}
+
+func exportHistory(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	symbols := fs.String("symbols", "", "comma separated symbols, all when empty")
+	fromRaw := fs.String("from", "", "first date, Jalali or Gregorian")
+	toRaw := fs.String("to", "", "last date, Jalali or Gregorian, included")
+	formatRaw := fs.String("format", "csv", "csv, xlsx or parquet")
+	kind := fs.String("kind", "history", "history or candles")
+	interval := fs.String("interval", "1d", "length of the candles, e.g. 4h or 1w")
+	names := fs.Bool("names", false, "add the English and Persian names")
+	jalali := fs.Bool("jalali", false, "add the Jalali date")
+	out := fs.String("out", "", "output file, standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, ok := export.ParseFormat(*formatRaw)
+	if !ok {
+		return usageError("--format must be csv, xlsx or parquet")
+	}
+	q := usecase.ExportQuery{
+		From:    time.Unix(0, 0),
+		To:      time.Now(),
+		Options: export.Options{Names: *names, Jalali: *jalali},
+	}
+	if *fromRaw != "" {
+		t, err := calendar.ParseDate(*fromRaw)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		q.From = t
+	}
+	if *toRaw != "" {
+		t, err := calendar.ParseDate(*toRaw)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+		q.To = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	switch *kind {
+	case "history":
+	case "candles":
+		d, err := usecase.ParseInterval(*interval)
+		if err != nil {
+			return fmt.Errorf("--interval: %w", err)
+		}
+		q.Interval = d
+	default:
+		return usageError("--kind must be history or candles")
+	}
+	for _, s := range strings.Split(*symbols, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			q.Symbols = append(q.Symbols, s)
+		}
+	}
+
+	database := openDB(cfg, true)
+	defer database.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	ew, err := export.NewWriter(format, w, q.Columns())
+	if err != nil {
+		return err
+	}
+	n, err := delivery.NewPriceUseCase(database, cfg).Export(context.Background(), ew, q, nil)
+	if err != nil {
+		return err
+	}
+	if err := ew.Close(); err != nil {
+		return err
+	}
+	if *out != "" {
+		fmt.Printf("exported %d rows to %s\n", n, *out)
+	}
+	return nil
+}
+
+// importPaths returns the files to import. Directories, when allowed, expand to
+// their .json and .csv files sorted by name.
+func importPaths(paths []string, dirs bool) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		if !dirs {
+			return nil, fmt.Errorf("%s is a directory, use backfill", p)
+		}
+		var matches []string
+		for _, pattern := range []string{"*.json", "*.csv"} {
+			m, err := filepath.Glob(filepath.Join(p, pattern))
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, m...)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no files to import")
+	}
+	return files, nil
+}
+
+func createAPIKey(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the key owner")
+	scopes := fs.String("scopes", entity.ScopeRead, "comma separated scopes: read, stream, admin")
+	rate := fs.Int("rate", 60, "requests per minute, 0 for unlimited")
+	quota := fs.Int64("quota", 0, "requests per day, 0 for unlimited")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("--name is required")
+	}
+
+	database := openDB(cfg, true)
+	defer database.Close()
+
+	k := entity.APIKey{
+		Name:       *name,
+		Scopes:     strings.Split(*scopes, ","),
+		RateLimit:  *rate,
+		DailyQuota: *quota,
+	}
+	raw, err := usecase.NewAPIKeyUseCase(mysql.NewRepository(database)).Create(context.Background(), &k)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created API key #%d (%s) with scopes %s\n", k.ID, k.Name, strings.Join(k.Scopes, ","))
+	fmt.Println("Store it now, it will not be shown again:")
+	fmt.Println(raw)
+	return nil
+}
@@ -1,63 +1,223 @@
-// Package config manages application configuration from environment variables.
+// Package config manages application configuration from a YAML or TOML file and environment variables.
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
 
+	"github.com/BurntSushi/toml"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Config is the typed application configuration. Values come from the defaults, then the
+// file named by CONFIG_FILE, then environment variables; *_file settings read secrets from files.
 type Config struct {
-	dbUser        string
-	dbPass        string
-	dbHost        string
-	dbName        string
-	DBDSN         string
-	Port          string
-	APIKey        string
-	BaseURL       string
-	FetchInterval int
-}
-
-func getEnvAsInt(key string, defaultVal int) int {
-	valueStr := os.Getenv(key)
-	if value := valueStr; value != "" {
-		if i, err := strconv.Atoi(value); err == nil {
-			return i
-		}
-	}
-	return defaultVal
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Cache    CacheConfig    `yaml:"cache" toml:"cache"`
+	Provider ProviderConfig `yaml:"provider" toml:"provider"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Telegram TelegramConfig `yaml:"telegram" toml:"telegram"`
+	Webhook  WebhookConfig  `yaml:"webhook" toml:"webhook"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+
+	// Alerts are global alert rules evaluated with the stored alerts; they can be reloaded
+	Alerts []AlertRule `yaml:"alerts" toml:"alerts"`
 }
 
-func Init() *Config {
-	if err := godotenv.Load(); err != nil {
-		log.Println("Note: .env file not found, using system environment variables")
+type ServerConfig struct {
+	Port        string   `yaml:"port" toml:"port"`
+	GRPCPort    string   `yaml:"grpc_port" toml:"grpc_port"`       // The gRPC server only starts when set
+	CORSOrigins []string `yaml:"cors_origins" toml:"cors_origins"` // Reloadable
+}
+
+type DatabaseConfig struct {
+	DSN          string `yaml:"dsn" toml:"dsn"` // Overrides the individual settings when set
+	Host         string `yaml:"host" toml:"host"`
+	Port         string `yaml:"port" toml:"port"`
+	User         string `yaml:"user" toml:"user"`
+	Password     string `yaml:"password" toml:"password"`
+	PasswordFile string `yaml:"password_file" toml:"password_file"`
+	Name         string `yaml:"name" toml:"name"`
+}
+
+// CacheConfig configures the optional Redis backend of the latest quote cache.
+type CacheConfig struct {
+	Host         string `yaml:"host" toml:"host"` // host or host:port; the cache stays in memory only when empty
+	Password     string `yaml:"password" toml:"password"`
+	PasswordFile string `yaml:"password_file" toml:"password_file"`
+	DB           int    `yaml:"db" toml:"db"`
+}
+
+type ProviderConfig struct {
+	BaseURL       string `yaml:"base_url" toml:"base_url"`
+	APIKey        string `yaml:"api_key" toml:"api_key"`
+	APIKeyFile    string `yaml:"api_key_file" toml:"api_key_file"`
+	FetchInterval int    `yaml:"fetch_interval" toml:"fetch_interval"` // Minutes, reloadable
+}
+
+type AuthConfig struct {
+	APIKeysEnabled  bool   `yaml:"api_keys_enabled" toml:"api_keys_enabled"` // Require an API key on every route except the public ones
+	Key             string `yaml:"key" toml:"key"`                           // Signs user access tokens; user accounts are disabled when empty
+	KeyFile         string `yaml:"key_file" toml:"key_file"`
+	ExpirationHours int    `yaml:"expiration_hours" toml:"expiration_hours"`
+}
+
+type TelegramConfig struct {
+	Token           string   `yaml:"token" toml:"token"`
+	TokenFile       string   `yaml:"token_file" toml:"token_file"`
+	APIURL          string   `yaml:"api_url" toml:"api_url"`
+	Channels        []string `yaml:"channels" toml:"channels"`
+	SummaryInterval int      `yaml:"summary_interval" toml:"summary_interval"` // Minutes between channel summaries, 0 disables them
+}
+
+type WebhookConfig struct {
+	AllowPrivate bool `yaml:"allow_private" toml:"allow_private"` // Let webhooks reach loopback and private addresses, for local receivers
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" toml:"format"` // json or text
+	Level  string `yaml:"level" toml:"level"`   // debug, info, warn or error
+}
+
+type TracingConfig struct {
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint"` // OTLP/HTTP collector URL; tracing is disabled when empty
+}
+
+// AlertRule is a global alert defined in the configuration file.
+type AlertRule struct {
+	Symbol          string  `yaml:"symbol" toml:"symbol"`
+	Kind            string  `yaml:"kind" toml:"kind"` // price, change or bubble
+	Condition       string  `yaml:"condition" toml:"condition"`
+	Threshold       float64 `yaml:"threshold" toml:"threshold"`
+	Unit            string  `yaml:"unit" toml:"unit"`
+	WindowSeconds   int64   `yaml:"window_seconds" toml:"window_seconds"`
+	CooldownSeconds int64   `yaml:"cooldown_seconds" toml:"cooldown_seconds"`
+}
+
+// Alert returns the rule as an alert entity. Rules always recur after their cooldown.
+func (r AlertRule) Alert() entity.Alert {
+	return entity.Alert{
+		Symbol:          r.Symbol,
+		Kind:            r.Kind,
+		Condition:       r.Condition,
+		Threshold:       r.Threshold,
+		Unit:            r.Unit,
+		WindowSeconds:   r.WindowSeconds,
+		CooldownSeconds: r.CooldownSeconds,
+		Mode:            entity.AlertRecurring,
 	}
+}
 
-	cfg := &Config{
-		Port:    os.Getenv("PORT"),
-		APIKey:  os.Getenv("API_KEY"),
-		BaseURL: os.Getenv("API_BASE_URL"),
-		dbUser:  os.Getenv("DB_USER"),
-		dbPass:  os.Getenv("DB_PASS"),
-		dbName:  os.Getenv("DB_NAME"),
-		dbHost:  os.Getenv("DB_HOST"),
+func defaults() *Config {
+	return &Config{
+		Server:   ServerConfig{Port: "8080", CORSOrigins: []string{"http://localhost:3000"}},
+		Database: DatabaseConfig{Port: "3306"},
+		Provider: ProviderConfig{FetchInterval: 1},
+		Auth:     AuthConfig{ExpirationHours: 24},
+		Telegram: TelegramConfig{APIURL: "https://api.telegram.org"},
+		Log:      LogConfig{Format: "text", Level: "info"},
 	}
+}
 
-	cfg.DBDSN = fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", cfg.dbUser, cfg.dbPass, cfg.dbHost, cfg.dbName)
+// DSN returns the MySQL data source name, built from the individual settings unless one was given.
+func (c *Config) DSN() string {
+	if c.Database.DSN != "" {
+		// Timestamps are scanned into time values, which needs parseTime whatever the DSN says
+		m, err := mysql.ParseDSN(c.Database.DSN)
+		if err != nil {
+			return c.Database.DSN
+		}
+		m.ParseTime = true
+		return m.FormatDSN()
+	}
+	m := mysql.NewConfig()
+	m.User = c.Database.User
+	m.Passwd = c.Database.Password
+	m.Net = "tcp"
+	m.Addr = c.Database.Host
+	if _, _, err := net.SplitHostPort(m.Addr); err != nil {
+		// A host without a port, the usual form; host:port is kept as given
+		m.Addr = net.JoinHostPort(c.Database.Host, c.Database.Port)
+	}
+	m.DBName = c.Database.Name
+	m.ParseTime = true
+	return m.FormatDSN()
+}
 
-	if cfg.Port == "" {
-		cfg.Port = "8080"
+// Load reads the configuration file at path, if any, applies environment overrides and
+// secret files, and validates the result. All problems are reported together.
+func Load(path string) (*Config, error) {
+	cfg := defaults()
+	if path != "" {
+		if err := decodeFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+	var problems []string
+	for _, err := range []error{applyEnv(cfg), readSecrets(cfg), cfg.Validate()} {
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			problems = append(problems, ve.Problems...)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
+	return cfg, nil
+}
 
-	if cfg.DBDSN == "" {
-		log.Fatal("Critical: DB_DSN is not set in environment")
+// decodeFile fills cfg from a YAML or TOML file, rejecting unknown keys.
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config file %s: unknown key %s", path, undecoded[0])
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
 	}
+	return nil
+}
 
-	cfg.FetchInterval = getEnvAsInt("FETCH_INTERVAL", 1)
+// LoadEnv loads .env, then the configuration from CONFIG_FILE and the environment.
+func LoadEnv() (*Config, error) {
+	if err := godotenv.Load(); err != nil {
+		slog.Info(".env file not found, using system environment variables")
+	}
+	return Load(os.Getenv("CONFIG_FILE"))
+}
 
+// Init is LoadEnv that exits with every validation error when the configuration is invalid.
+func Init() *Config {
+	cfg, err := LoadEnv()
+	if err != nil {
+		slog.Error("invalid configuration", "err", err)
+		os.Exit(1)
+	}
 	return cfg
 }
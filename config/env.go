@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envVar maps one environment variable onto the configuration.
+type envVar struct {
+	name  string
+	apply func(c *Config, v string) error
+}
+
+var envVars = []envVar{
+	{"PORT", setString(func(c *Config) *string { return &c.Server.Port })},
+	{"GRPC_PORT", setString(func(c *Config) *string { return &c.Server.GRPCPort })},
+	{"CORS_ORIGINS", setList(func(c *Config) *[]string { return &c.Server.CORSOrigins })},
+
+	{"DB_DSN", setString(func(c *Config) *string { return &c.Database.DSN })},
+	{"DB_HOST", setString(func(c *Config) *string { return &c.Database.Host })},
+	{"DB_PORT", setString(func(c *Config) *string { return &c.Database.Port })},
+	{"DB_USER", setString(func(c *Config) *string { return &c.Database.User })},
+	{"DB_PASS", setString(func(c *Config) *string { return &c.Database.Password })},
+	{"DB_PASS_FILE", setString(func(c *Config) *string { return &c.Database.PasswordFile })},
+	{"DB_NAME", setString(func(c *Config) *string { return &c.Database.Name })},
+
+	{"CACHE_HOST", setString(func(c *Config) *string { return &c.Cache.Host })},
+	{"CACHE_PASSWORD", setString(func(c *Config) *string { return &c.Cache.Password })},
+	{"CACHE_PASSWORD_FILE", setString(func(c *Config) *string { return &c.Cache.PasswordFile })},
+	{"CACHE_DB", setInt(func(c *Config) *int { return &c.Cache.DB })},
+
+	{"API_BASE_URL", setString(func(c *Config) *string { return &c.Provider.BaseURL })},
+	{"API_KEY", setString(func(c *Config) *string { return &c.Provider.APIKey })},
+	{"API_KEY_FILE", setString(func(c *Config) *string { return &c.Provider.APIKeyFile })},
+	{"FETCH_INTERVAL", setInt(func(c *Config) *int { return &c.Provider.FetchInterval })},
+
+	{"API_AUTH_ENABLED", setBool(func(c *Config) *bool { return &c.Auth.APIKeysEnabled })},
+	{"AUTH_KEY", setString(func(c *Config) *string { return &c.Auth.Key })},
+	{"AUTH_KEY_FILE", setString(func(c *Config) *string { return &c.Auth.KeyFile })},
+	{"AUTH_EXPIRATION_HOURS", setInt(func(c *Config) *int { return &c.Auth.ExpirationHours })},
+
+	{"TELEGRAM_BOT_TOKEN", setString(func(c *Config) *string { return &c.Telegram.Token })},
+	{"TELEGRAM_BOT_TOKEN_FILE", setString(func(c *Config) *string { return &c.Telegram.TokenFile })},
+	{"TELEGRAM_API_URL", setString(func(c *Config) *string { return &c.Telegram.APIURL })},
+	{"TELEGRAM_CHANNELS", setList(func(c *Config) *[]string { return &c.Telegram.Channels })},
+	{"TELEGRAM_SUMMARY_INTERVAL", setInt(func(c *Config) *int { return &c.Telegram.SummaryInterval })},
+
+	{"WEBHOOK_ALLOW_PRIVATE", setBool(func(c *Config) *bool { return &c.Webhook.AllowPrivate })},
+
+	{"LOG_FORMAT", setString(func(c *Config) *string { return &c.Log.Format })},
+	{"LOG_LEVEL", setString(func(c *Config) *string { return &c.Log.Level })},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", setString(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+}
+
+// applyEnv overrides the configuration with every non-empty environment variable in envVars.
+func applyEnv(c *Config) error {
+	var problems []string
+	for _, e := range envVars {
+		v := os.Getenv(e.name)
+		if v == "" {
+			continue
+		}
+		if err := e.apply(c, v); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", e.name, err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// readSecrets replaces secrets with the trimmed content of their *_file settings.
+func readSecrets(c *Config) error {
+	secrets := []struct {
+		name  string
+		file  string
+		value *string
+	}{
+		{"database.password_file", c.Database.PasswordFile, &c.Database.Password},
+		{"cache.password_file", c.Cache.PasswordFile, &c.Cache.Password},
+		{"provider.api_key_file", c.Provider.APIKeyFile, &c.Provider.APIKey},
+		{"auth.key_file", c.Auth.KeyFile, &c.Auth.Key},
+		{"telegram.token_file", c.Telegram.TokenFile, &c.Telegram.Token},
+	}
+	var problems []string
+	for _, s := range secrets {
+		if s.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		*s.value = strings.TrimSpace(string(data))
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+		*field(c) = i
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// setList splits a comma separated value, dropping empty items.
+func setList(field func(*Config) *[]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*field(c) = items
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+const minAuthKeyLength = 16
+
+// Validate checks the whole configuration and reports all problems at once.
+func (c *Config) Validate() error {
+	var p []string
+	add := func(format string, args ...interface{}) { p = append(p, fmt.Sprintf(format, args...)) }
+
+	if !validPort(c.Server.Port) {
+		add("server.port (PORT) must be a port number, got %q", c.Server.Port)
+	}
+	if c.Server.GRPCPort != "" && !validPort(c.Server.GRPCPort) {
+		add("server.grpc_port (GRPC_PORT) must be a port number, got %q", c.Server.GRPCPort)
+	} else if c.Server.GRPCPort != "" && c.Server.GRPCPort == c.Server.Port {
+		add("server.grpc_port (GRPC_PORT) must differ from server.port (PORT)")
+	}
+	for _, o := range c.Server.CORSOrigins {
+		if o != "*" && !validOrigin(o) {
+			add("server.cors_origins (CORS_ORIGINS): %q is not an origin such as https://example.com", o)
+		}
+	}
+
+	if c.Database.DSN != "" {
+		if _, err := mysql.ParseDSN(c.Database.DSN); err != nil {
+			add("database.dsn (DB_DSN) is not a valid MySQL DSN: %v", err)
+		}
+	} else {
+		if c.Database.Host == "" {
+			add("database.host (DB_HOST) is required")
+		}
+		if c.Database.User == "" {
+			add("database.user (DB_USER) is required")
+		}
+		if c.Database.Name == "" {
+			add("database.name (DB_NAME) is required")
+		}
+		if !validPort(c.Database.Port) {
+			add("database.port (DB_PORT) must be a port number, got %q", c.Database.Port)
+		}
+	}
+
+	if c.Cache.DB < 0 {
+		add("cache.db (CACHE_DB) cannot be negative")
+	}
+
+	if c.Provider.BaseURL == "" {
+		add("provider.base_url (API_BASE_URL) is required")
+	} else if !validURL(c.Provider.BaseURL) {
+		add("provider.base_url (API_BASE_URL) must be an http or https URL, got %q", c.Provider.BaseURL)
+	}
+	if c.Provider.FetchInterval < 1 {
+		add("provider.fetch_interval (FETCH_INTERVAL) must be at least 1 minute")
+	}
+
+	if c.Auth.Key != "" && len(c.Auth.Key) < minAuthKeyLength {
+		add("auth.key (AUTH_KEY) must be at least %d characters", minAuthKeyLength)
+	}
+	if c.Auth.ExpirationHours < 1 {
+		add("auth.expiration_hours (AUTH_EXPIRATION_HOURS) must be at least 1")
+	}
+
+	if !validURL(c.Telegram.APIURL) {
+		add("telegram.api_url (TELEGRAM_API_URL) must be an http or https URL, got %q", c.Telegram.APIURL)
+	}
+	if c.Telegram.SummaryInterval < 0 {
+		add("telegram.summary_interval (TELEGRAM_SUMMARY_INTERVAL) cannot be negative")
+	}
+
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		add("log.format (LOG_FORMAT) must be json or text, got %q", c.Log.Format)
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+
+	if c.Tracing.OTLPEndpoint != "" && !validURL(c.Tracing.OTLPEndpoint) {
+		add("tracing.otlp_endpoint (OTEL_EXPORTER_OTLP_ENDPOINT) must be an http or https URL, got %q", c.Tracing.OTLPEndpoint)
+	}
+
+	// Rules follow the same checks as alerts created through the API
+	for i, r := range c.Alerts {
+		a := r.Alert()
+		if err := a.Normalize(); err != nil {
+			add("alerts[%d]: %v", i, err)
+		}
+	}
+
+	if len(p) > 0 {
+		return &ValidationError{Problems: p}
+	}
+	return nil
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n < 65536
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/")
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const watchInterval = 10 * time.Second
+
+// Watcher reloads the configuration file when it changes or on SIGHUP. Only the
+// fetch interval, alert rules and CORS origins are applied while running; other
+// changes are logged and need a restart.
+type Watcher struct {
+	path    string
+	mu      sync.Mutex
+	current *Config
+	modTime time.Time
+	reloads []func(*Config)
+}
+
+func NewWatcher(path string, cfg *Config) *Watcher {
+	w := &Watcher{path: path, current: cfg}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// OnReload registers a callback that receives every successfully reloaded configuration.
+// It is safe to call on a nil Watcher, which never reloads.
+func (w *Watcher) OnReload(fn func(*Config)) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.reloads = append(w.reloads, fn)
+	w.mu.Unlock()
+}
+
+// Run polls the file and listens for SIGHUP until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.reload("signal")
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil || info.ModTime().Equal(w.modTime) {
+				continue
+			}
+			w.modTime = info.ModTime()
+			w.reload("file changed")
+		}
+	}
+}
+
+// reload loads the file again and applies it, keeping the running configuration when it is invalid.
+func (w *Watcher) reload(reason string) {
+	cfg, err := Load(w.path)
+	if err != nil {
+		slog.Error("config reload failed, keeping current configuration", "reason", reason, "err", err)
+		return
+	}
+
+	w.mu.Lock()
+	if !reflect.DeepEqual(withoutReloadable(*w.current), withoutReloadable(*cfg)) {
+		slog.Warn("config changes other than fetch_interval, alerts and cors_origins need a restart")
+	}
+	w.current = cfg
+	reloads := w.reloads
+	w.mu.Unlock()
+
+	for _, fn := range reloads {
+		fn(cfg)
+	}
+	slog.Info("config reloaded", "reason", reason, "fetch_interval_minutes", cfg.Provider.FetchInterval, "alert_rules", len(cfg.Alerts))
+}
+
+// withoutReloadable clears the settings that are applied on reload.
+func withoutReloadable(c Config) Config {
+	c.Provider.FetchInterval = 0
+	c.Server.CORSOrigins = nil
+	c.Alerts = nil
+	return c
+}
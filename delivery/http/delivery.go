@@ -2,33 +2,215 @@
 package delivery
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/ar-mokhtari/market-tracker/adapter/storage/mysql"
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/redis"
 	config "github.com/ar-mokhtari/market-tracker/config"
+	"github.com/ar-mokhtari/market-tracker/delivery/http/openapi"
 	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	v2 "github.com/ar-mokhtari/market-tracker/delivery/http/v2"
+	"github.com/ar-mokhtari/market-tracker/delivery/rpc"
+	"github.com/ar-mokhtari/market-tracker/delivery/telegram"
 	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/metrics"
+	"github.com/ar-mokhtari/market-tracker/tracing"
 	"github.com/ar-mokhtari/market-tracker/usecase"
 )
 
-func Init(db *sql.DB, cfg *config.Config, hub *v1.Hub) *http.ServeMux {
-	repo := mysql.NewRepository(db)
+// Init wires the use cases, background workers and routes. A non-nil watcher
+// applies the reloadable settings whenever the configuration file changes. The gRPC
+// API is nil unless GRPC_PORT is set; the caller serves it next to the handler.
+func Init(db *sql.DB, cfg *config.Config, hub *v1.Hub, watcher *config.Watcher) (http.Handler, *rpc.API) {
+	// Price repository calls are traced; spans are only exported when tracing is configured
+	repo := mysql.NewTracedRepository(db)
 
-	// Pass FetchInterval directly from cfg to NewPriceUseCase
-	uc := usecase.NewPriceUseCase(repo, cfg.APIKey, cfg.BaseURL, cfg.FetchInterval)
+	uc := NewPriceUseCase(db, cfg)
+
+	// Latest prices are served from memory; an empty cache only costs the first request a query
+	if err := uc.WarmQuotes(context.Background()); err != nil {
+		slog.Warn("quote cache not warmed", "err", err)
+	}
+
+	// Alerts are evaluated after every fetch and delivered through the hub
+	alertUC := usecase.NewAlertUseCase(repo, repo)
+	alertUC.AddNotifier(usecase.NotifierFunc(hub.NotifyAlert))
+	if err := alertUC.SetRules(alertRules(cfg)); err != nil {
+		slog.Error("invalid alert rule in configuration", "err", err)
+		os.Exit(1)
+	}
+
+	// The fetch interval and alert rules follow configuration reloads
+	watcher.OnReload(func(c *config.Config) {
+		uc.SetFetchInterval(fetchInterval(c))
+		if err := alertUC.SetRules(alertRules(c)); err != nil {
+			slog.Error("alert rules not reloaded", "err", err)
+		}
+	})
+
+	// Webhooks receive price changes and alerts through a persistent delivery queue
+	webhookUC := usecase.NewWebhookUseCase(repo, cfg.Webhook.AllowPrivate)
+	alertUC.AddNotifier(webhookUC)
+	go webhookUC.StartDelivery(context.Background())
+
+	// The Telegram bot is optional and only starts when a token is configured
+	if cfg.Telegram.Token != "" {
+		bot := telegram.NewBot(telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.Token), uc, alertUC, repo,
+			cfg.Telegram.Channels, time.Duration(cfg.Telegram.SummaryInterval)*time.Minute)
+		alertUC.AddNotifier(bot)
+		go bot.Run(context.Background())
+	}
+
+	// Portfolios are revalued after every fetch for their connected owners
+	portfolioUC := usecase.NewPortfolioUseCase(repo, repo, uc)
 
 	// Setup Callback to push data to WebSocket Hub
-	uc.OnUpdate = func(prices []entity.Price) {
-		hub.BroadcastUpdate(map[string]interface{}{"data": prices})
+	uc.OnUpdate = func(ctx context.Context, prices []entity.Price) {
+		hub.BroadcastPrices(prices)
+		slog.DebugContext(ctx, "prices broadcast", "symbols", len(prices), "clients", hub.ClientCount(), "subscribers", hub.SubscriberCount())
+		webhookUC.PricesChanged(ctx, prices)
+		alertUC.Evaluate(ctx, prices)
+		if users := hub.ConnectedUsers(); len(users) > 0 {
+			valuations, err := portfolioUC.UserValuations(ctx, users)
+			if err != nil {
+				slog.ErrorContext(ctx, "portfolio valuation failed", "err", err)
+			}
+			hub.NotifyPortfolios(valuations)
+		}
 	}
+	hub.SetUnitConverter(func(prices []entity.Price, unit entity.Unit) ([]entity.Price, error) {
+		return uc.ConvertUnits(context.Background(), prices, unit)
+	})
 
 	// Start the single worker in background
 	go uc.StartAutomation()
 
-	h := v1.NewPriceHandler(uc, hub)
+	watchlistUC := usecase.NewWatchlistUseCase(repo, uc)
+	hub.SetWatchlistResolver(watchlistUC.Symbols)
+	apiKeyUC := usecase.NewAPIKeyUseCase(repo)
+
+	// Readiness covers the database, schema, fetcher, provider circuit and hub; a fetch
+	// older than three intervals (and at least five minutes) marks the fetcher down
+	healthUC := usecase.NewHealthUseCase()
+	healthUC.AddCheck("database", databaseCheck(db))
+	healthUC.AddCheck("migrations", migrationCheck(db))
+	healthUC.AddCheck("fetcher", uc.FetcherCheck(5*time.Minute))
+	healthUC.AddCheck("provider", uc.ProviderCheck())
+	healthUC.AddCheck("hub", hubCheck(hub))
+
+	// Prometheus scrapes fetch, storage, HTTP and websocket metrics from /metrics
+	metrics.RegisterDB(db, "mysql")
+	metrics.RegisterWebsocketClients(hub.ClientCount)
+
+	// User accounts sign their access tokens with AUTH_KEY and are disabled without it
+	var userUC *usecase.UserUseCase
+	if cfg.Auth.Key != "" {
+		userUC = usecase.NewUserUseCase(repo, cfg.Auth.Key, time.Duration(cfg.Auth.ExpirationHours)*time.Hour)
+	}
+
 	mux := http.NewServeMux()
-	h.RegisterRoutes(mux)
+	// Routes are recorded to check them against the OpenAPI document once all are registered
+	routes := v1.NewRouteRecorder(mux)
+	RegisterRoutes(routes, Services{
+		Prices:     uc,
+		Hub:        hub,
+		Alerts:     alertUC,
+		Webhooks:   webhookUC,
+		Watchlists: watchlistUC,
+		Portfolios: portfolioUC,
+		APIKeys:    apiKeyUC,
+		Health:     healthUC,
+		Users:      userUC,
+	})
+	if err := openapi.Check(routes.Patterns()); err != nil {
+		slog.Error("openapi document out of date", "err", err)
+	}
+
+	// The gRPC API serves the quotes and the hub's price stream on its own port
+	var grpcAPI *rpc.API
+	if cfg.Server.GRPCPort != "" {
+		var keys *usecase.APIKeyUseCase
+		if cfg.Auth.APIKeysEnabled {
+			keys = apiKeyUC
+		}
+		grpcAPI = rpc.NewAPI(rpc.NewServer(uc, hub), keys, healthUC)
+	}
+
+	var handler http.Handler = mux
+	if cfg.Auth.APIKeysEnabled {
+		go apiKeyUC.StartUsageFlusher(context.Background())
+		handler = v1.AuthMiddleware(apiKeyUC, handler)
+	}
+	if userUC != nil {
+		handler = v1.UserMiddleware(userUC, handler)
+	}
+
+	// Text and JSON responses are compressed with brotli or gzip
+	handler = v1.CompressMiddleware(handler)
+	return tracing.Middleware(mux, v1.RequestIDMiddleware(metrics.Middleware(mux, handler))), grpcAPI
+}
+
+// Services are the use cases behind the HTTP routes.
+type Services struct {
+	Prices     *usecase.PriceUseCase
+	Hub        *v1.Hub
+	Alerts     *usecase.AlertUseCase
+	Webhooks   *usecase.WebhookUseCase
+	Watchlists *usecase.WatchlistUseCase
+	Portfolios *usecase.PortfolioUseCase
+	APIKeys    *usecase.APIKeyUseCase
+	Health     *usecase.HealthUseCase
+	Users      *usecase.UserUseCase // nil when user accounts are disabled
+}
+
+// RegisterRoutes registers every HTTP route of the server on mux.
+func RegisterRoutes(mux v1.Mux, s Services) {
+	v1.NewPriceHandler(s.Prices, s.Hub).RegisterRoutes(mux)
+	v1.NewAlertHandler(s.Alerts).RegisterRoutes(mux)
+	v1.NewWebhookHandler(s.Webhooks).RegisterRoutes(mux)
+	// v2 serves the price data in one envelope with problem details; v1 keeps its formats
+	v2.NewHandler(s.Prices).RegisterRoutes(mux)
+
+	// Watchlists are personal and can also narrow a WebSocket connection's price stream
+	v1.NewWatchlistHandler(s.Watchlists).RegisterRoutes(mux)
+	v1.NewPortfolioHandler(s.Portfolios).RegisterRoutes(mux)
+
+	// API keys are managed through the admin endpoints, which refuse every request while keys are disabled
+	v1.NewAPIKeyHandler(s.APIKeys).RegisterRoutes(mux)
+	v1.NewHealthHandler(s.Health).RegisterRoutes(mux)
+	mux.Handle("GET /metrics", metrics.Handler())
+	if s.Users != nil {
+		v1.NewUserHandler(s.Users).RegisterRoutes(mux)
+	}
+
+	// The OpenAPI document describes every route and the websocket messages; /docs renders it
+	openapi.RegisterRoutes(mux)
+}
 
-	return mux
+// NewPriceUseCase builds the price use case the server runs; commands use it to share the wiring.
+// The latest quotes are shared through Redis when CACHE_HOST is set.
+func NewPriceUseCase(db *sql.DB, cfg *config.Config) *usecase.PriceUseCase {
+	uc := usecase.NewPriceUseCase(mysql.NewTracedRepository(db), cfg.Provider.APIKey, cfg.Provider.BaseURL, fetchInterval(cfg))
+	if cfg.Cache.Host != "" {
+		uc.SetQuoteStore(redis.NewQuoteStore(cfg.Cache.Host, cfg.Cache.Password, cfg.Cache.DB))
+	}
+	return uc
+}
+
+func fetchInterval(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Provider.FetchInterval) * time.Minute
+}
+
+// alertRules converts the configured global alerts to alert entities.
+func alertRules(cfg *config.Config) []entity.Alert {
+	rules := make([]entity.Alert, 0, len(cfg.Alerts))
+	for _, r := range cfg.Alerts {
+		rules = append(rules, r.Alert())
+	}
+	return rules
 }
@@ -0,0 +1,60 @@
+package delivery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/migration"
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// databaseCheck pings MySQL and reports the connection pool usage.
+func databaseCheck(db *sql.DB) usecase.HealthCheck {
+	return func(ctx context.Context) usecase.ComponentHealth {
+		stats := db.Stats()
+		result := usecase.ComponentHealth{Status: usecase.HealthUp, Detail: map[string]interface{}{
+			"open_connections": stats.OpenConnections,
+			"in_use":           stats.InUse,
+			"max_open":         stats.MaxOpenConnections,
+		}}
+		if err := db.PingContext(ctx); err != nil {
+			result.Status = usecase.HealthDown
+			result.Error = err.Error()
+		}
+		return result
+	}
+}
+
+// migrationCheck reports the schema down when it is behind the migrations embedded in the binary.
+func migrationCheck(db *sql.DB) usecase.HealthCheck {
+	return func(context.Context) usecase.ComponentHealth {
+		latest := migration.Latest()
+		result := usecase.ComponentHealth{Status: usecase.HealthUp, Detail: map[string]interface{}{"expected": latest}}
+		version, err := migration.Version(db)
+		if err != nil {
+			result.Status = usecase.HealthDown
+			result.Error = err.Error()
+			return result
+		}
+		result.Detail["version"] = version
+		if version < latest {
+			result.Status = usecase.HealthDown
+			result.Error = fmt.Sprintf("schema version %d is behind %d", version, latest)
+		}
+		return result
+	}
+}
+
+// hubCheck reports the websocket hub down when its broadcast loop is not running.
+func hubCheck(hub *v1.Hub) usecase.HealthCheck {
+	return func(context.Context) usecase.ComponentHealth {
+		result := usecase.ComponentHealth{Status: usecase.HealthUp, Detail: map[string]interface{}{"clients": hub.ClientCount()}}
+		if !hub.Running() {
+			result.Status = usecase.HealthDown
+			result.Error = "broadcast loop is not running"
+		}
+		return result
+	}
+}
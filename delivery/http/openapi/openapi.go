@@ -0,0 +1,102 @@
+// Package openapi serves the OpenAPI description of the HTTP API and its documentation page.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+)
+
+var (
+	//go:embed openapi.json
+	spec []byte
+
+	//go:embed docs.html
+	docsPage []byte
+)
+
+// methods are the operations of an OpenAPI path item.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// RegisterRoutes serves the document at /openapi.json and the documentation page at /docs.
+func RegisterRoutes(mux v1.Mux) {
+	mux.HandleFunc("GET /openapi.json", serve("application/json", spec))
+	mux.HandleFunc("GET /docs", serve("text/html; charset=utf-8", docsPage))
+}
+
+func serve(contentType string, body []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Write(body)
+	}
+}
+
+// Check compares the registered route patterns, as given to http.ServeMux, with the
+// operations of the document. It reports routes missing from the document and documented
+// operations that are not served. Patterns without a method match every documented
+// method of their path; operations marked x-requires are only served when the named
+// setting is configured, so they may be missing. Subtree patterns ending in a slash only
+// catch unknown paths and are not compared.
+func Check(patterns []string) error {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("openapi document: %w", err)
+	}
+
+	served := make(map[string]bool) // "METHOD path", or "* path" for patterns without a method
+	var problems []string
+	for _, pattern := range patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			method, path = "*", pattern
+		}
+		if strings.HasSuffix(path, "/") {
+			continue
+		}
+		served[method+" "+path] = true
+
+		ops, documented := doc.Paths[path]
+		switch {
+		case !documented:
+			problems = append(problems, "undocumented route "+pattern)
+		case method != "*" && ops[strings.ToLower(method)] == nil:
+			problems = append(problems, "undocumented operation "+pattern)
+		}
+	}
+
+	for path, ops := range doc.Paths {
+		for _, method := range methods {
+			raw, ok := ops[method]
+			if !ok {
+				continue
+			}
+			upper := strings.ToUpper(method)
+			if served[upper+" "+path] || served["* "+path] {
+				continue
+			}
+			var op struct {
+				Requires string `json:"x-requires"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return fmt.Errorf("openapi document: %s %s: %w", upper, path, err)
+			}
+			if op.Requires == "" {
+				problems = append(problems, "documented operation not served "+upper+" "+path)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return fmt.Errorf("openapi document and routes differ: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package openapi_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	delivery "github.com/ar-mokhtari/market-tracker/delivery/http"
+	"github.com/ar-mokhtari/market-tracker/delivery/http/openapi"
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// TestDocumentMatchesRoutes registers the routes of the server, with user accounts
+// enabled, and fails when the OpenAPI document and the routes differ.
+func TestDocumentMatchesRoutes(t *testing.T) {
+	prices := usecase.NewPriceUseCase(nil, "", "", time.Minute)
+	routes := v1.NewRouteRecorder(http.NewServeMux())
+	delivery.RegisterRoutes(routes, delivery.Services{
+		Prices:     prices,
+		Hub:        v1.NewHub(),
+		Alerts:     usecase.NewAlertUseCase(nil, nil),
+		Webhooks:   usecase.NewWebhookUseCase(nil, false),
+		Watchlists: usecase.NewWatchlistUseCase(nil, prices),
+		Portfolios: usecase.NewPortfolioUseCase(nil, nil, prices),
+		APIKeys:    usecase.NewAPIKeyUseCase(nil),
+		Health:     usecase.NewHealthUseCase(),
+		Users:      usecase.NewUserUseCase(nil, "test-key", time.Hour),
+	})
+
+	if len(routes.Patterns()) == 0 {
+		t.Fatal("no routes registered")
+	}
+	if err := openapi.Check(routes.Patterns()); err != nil {
+		t.Fatal(err)
+	}
+}
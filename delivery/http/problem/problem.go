@@ -0,0 +1,83 @@
+// Package problem writes errors as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Error codes. Every problem carries one; clients should branch on it rather than on the detail.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnknownSymbol    = "unknown_symbol"
+	CodeNoConversionPath = "no_conversion_path"
+	CodeConflict         = "conflict"
+	CodeRateLimited      = "rate_limited"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+)
+
+// typePrefix makes the codes problem type URIs.
+const typePrefix = "urn:market-tracker:problem:"
+
+// Details is a problem details object with the code and request ID as extension members.
+type Details struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Write sends a problem with the given status and code. The instance is the request path
+// and the request ID is the one the response already carries.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	p := Details{
+		Type:      typePrefix + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: w.Header().Get("X-Request-ID"),
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		http.Error(w, detail, status)
+	}
+}
+
+// CodeFor returns the generic code of a status, for errors without a more specific one.
+func CodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
@@ -0,0 +1,175 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type AlertHandler struct {
+	uc *usecase.AlertUseCase
+}
+
+func NewAlertHandler(uc *usecase.AlertUseCase) *AlertHandler {
+	return &AlertHandler{uc: uc}
+}
+
+// owner resolves the user whose alerts a request may see, writing the error response when
+// the request is not allowed.
+type owner func(w http.ResponseWriter, r *http.Request) (uint, bool)
+
+func (h *AlertHandler) RegisterRoutes(mux Mux) {
+	// Signed-in users manage their own alerts
+	mux.HandleFunc("GET /api/v1/alerts", h.List(requireUser))
+	mux.HandleFunc("POST /api/v1/alerts", h.Create(requireUser))
+	mux.HandleFunc("GET /api/v1/alerts/{id}", h.Get(requireUser))
+	mux.HandleFunc("DELETE /api/v1/alerts/{id}", h.Delete(requireUser))
+	mux.HandleFunc("GET /api/v1/alerts/{id}/events", h.Events(requireUser))
+
+	// Admin keys see the alerts of every user; the alerts they create have no owner
+	mux.HandleFunc("GET /api/v1/admin/alerts", h.List(requireAdminKey))
+	mux.HandleFunc("POST /api/v1/admin/alerts", h.Create(func(w http.ResponseWriter, r *http.Request) (uint, bool) {
+		_, ok := requireAdminKey(w, r)
+		return 0, ok
+	}))
+	mux.HandleFunc("GET /api/v1/admin/alerts/{id}", h.Get(requireAdminKey))
+	mux.HandleFunc("DELETE /api/v1/admin/alerts/{id}", h.Delete(requireAdminKey))
+	mux.HandleFunc("GET /api/v1/admin/alerts/{id}/events", h.Events(requireAdminKey))
+}
+
+// List maps to GET /api/v1/alerts and GET /api/v1/admin/alerts
+func (h *AlertHandler) List(owner owner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := owner(w, r)
+		if !ok {
+			return
+		}
+		params, ok := parseList(w, r)
+		if !ok {
+			return
+		}
+		alerts, err := h.uc.List(r.Context(), userID)
+		if err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		if alerts == nil {
+			alerts = []entity.Alert{}
+		}
+		page, next, err := pageAfter(alerts, func(v entity.Alert) listKey { return idKey(v.ID) }, false, params)
+		if err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		writeList(w, page, next, params.Fields)
+	}
+}
+
+// Create maps to POST /api/v1/alerts and POST /api/v1/admin/alerts
+func (h *AlertHandler) Create(owner owner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := owner(w, r)
+		if !ok {
+			return
+		}
+		var req dto.AlertRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		alert := entity.Alert{
+			Symbol:          req.Symbol,
+			Kind:            req.Kind,
+			Condition:       req.Condition,
+			Threshold:       req.Threshold,
+			Unit:            req.Unit,
+			WindowSeconds:   req.WindowSeconds,
+			Mode:            req.Mode,
+			CooldownSeconds: req.CooldownSeconds,
+			UserID:          userID,
+		}
+		if err := h.uc.Create(r.Context(), &alert); err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"data": alert})
+	}
+}
+
+// Get maps to GET /api/v1/alerts/{id} and GET /api/v1/admin/alerts/{id}
+func (h *AlertHandler) Get(owner owner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := owner(w, r)
+		if !ok {
+			return
+		}
+		id, ok := pathID(r)
+		if !ok {
+			writeError(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		alert, err := h.uc.Get(r.Context(), id, userID)
+		if err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": alert})
+	}
+}
+
+// Delete maps to DELETE /api/v1/alerts/{id} and DELETE /api/v1/admin/alerts/{id}
+func (h *AlertHandler) Delete(owner owner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := owner(w, r)
+		if !ok {
+			return
+		}
+		id, ok := pathID(r)
+		if !ok {
+			writeError(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		if err := h.uc.Delete(r.Context(), id, userID); err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Events maps to GET /api/v1/alerts/{id}/events and GET /api/v1/admin/alerts/{id}/events
+func (h *AlertHandler) Events(owner owner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := owner(w, r)
+		if !ok {
+			return
+		}
+		id, ok := pathID(r)
+		if !ok {
+			writeError(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		params, ok := parseList(w, r)
+		if !ok {
+			return
+		}
+		events, err := h.uc.Events(r.Context(), id, userID)
+		if err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		if events == nil {
+			events = []entity.AlertEvent{}
+		}
+		page, next, err := pageAfter(events, func(v entity.AlertEvent) listKey { return listKey{v.TriggeredAt.Unix(), v.ID} }, true, params)
+		if err != nil {
+			writeError(w, err.Error(), statusFor(err))
+			return
+		}
+		writeList(w, page, next, params.Fields)
+	}
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+const defaultUsageDays = 30
+
+type APIKeyHandler struct {
+	uc *usecase.APIKeyUseCase
+}
+
+func NewAPIKeyHandler(uc *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{uc: uc}
+}
+
+// RegisterRoutes registers the key management routes. Every one of them needs an admin key,
+// so keys cannot be managed while API keys are disabled; use the apikey command instead.
+func (h *APIKeyHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /api/v1/admin/apikeys", h.List)
+	mux.HandleFunc("POST /api/v1/admin/apikeys", h.Create)
+	mux.HandleFunc("DELETE /api/v1/admin/apikeys/{id}", h.Revoke)
+	mux.HandleFunc("GET /api/v1/admin/apikeys/{id}/usage", h.Usage)
+}
+
+// List maps to GET /api/v1/admin/apikeys
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	keys, err := h.uc.List(r.Context())
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if keys == nil {
+		keys = []entity.APIKey{}
+	}
+	page, next, err := pageAfter(keys, func(v entity.APIKey) listKey { return idKey(v.ID) }, false, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// Create maps to POST /api/v1/admin/apikeys
+// The response is the only place the raw key is returned.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	var req dto.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	k := entity.APIKey{
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		RateLimit:  req.RateLimit,
+		DailyQuota: req.DailyQuota,
+	}
+	raw, err := h.uc.Create(r.Context(), &k)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": map[string]interface{}{"key": raw, "api_key": k}})
+}
+
+// Revoke maps to DELETE /api/v1/admin/apikeys/{id}
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Revoke(r.Context(), id); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Usage maps to GET /api/v1/admin/apikeys/{id}/usage?days=30
+func (h *APIKeyHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	days := defaultUsageDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeError(w, "invalid days", http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	usage, err := h.uc.Usage(r.Context(), id, days)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if usage == nil {
+		usage = []entity.APIKeyUsage{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": usage})
+}
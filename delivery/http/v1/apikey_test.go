@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+func TestAPIKeyRoutesNeedAdminKey(t *testing.T) {
+	mux := http.NewServeMux()
+	// The use case is never reached, so it needs no repository
+	NewAPIKeyHandler(usecase.NewAPIKeyUseCase(nil)).RegisterRoutes(mux)
+
+	tests := []struct {
+		name string
+		key  *entity.APIKey
+	}{
+		{name: "without a key"},
+		{name: "with a read key", key: &entity.APIKey{ID: 1, Scopes: []string{entity.ScopeRead}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/apikeys", strings.NewReader(`{"name":"x","scopes":["admin"]}`))
+			if tt.key != nil {
+				r = r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, *tt.key))
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type apiKeyContextKey struct{}
+
+type userContextKey struct{}
+
+// APIKeyFromContext returns the key that authenticated the request, if any.
+func APIKeyFromContext(ctx context.Context) (entity.APIKey, bool) {
+	k, ok := ctx.Value(apiKeyContextKey{}).(entity.APIKey)
+	return k, ok
+}
+
+// UserFromContext returns the ID of the user whose access token came with the request, if any.
+func UserFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userContextKey{}).(uint)
+	return id, ok
+}
+
+// userID returns the signed-in user of a request, or 0 for anonymous and API key requests.
+func userID(r *http.Request) uint {
+	id, _ := UserFromContext(r.Context())
+	return id
+}
+
+// personalRoutes are owned by the signed-in user, so users may write to them without the admin scope.
+var personalRoutes = []string{"/api/v1/me", "/api/v1/alerts", "/api/v1/watchlists", "/api/v1/portfolios"}
+
+// UserMiddleware resolves JWT access tokens to users. Requests without a token pass through
+// unchanged; an invalid or expired token is rejected. Browser WebSockets may send ?token=.
+func UserMiddleware(users *usecase.UserUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := userTokenFromRequest(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, err := users.ParseToken(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="market-tracker", error="invalid_token"`)
+			writeRequestError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, id)))
+	})
+}
+
+// requireUser writes a 401 and returns false when the request has no signed-in user.
+func requireUser(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, ok := UserFromContext(r.Context())
+	if !ok {
+		writeError(w, "sign in required", http.StatusUnauthorized)
+	}
+	return id, ok
+}
+
+// AuthMiddleware requires an API key with the scope the route needs and enforces its rate limit.
+// Keys are read from "Authorization: Bearer", "X-API-Key" or, for browser WebSockets, ?api_key=.
+func AuthMiddleware(auth *usecase.APIKeyUseCase, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := requiredScope(r)
+		if scope == "" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Signed-in users manage their own resources without a key, under a per-user rate
+		// limit; every other route needs a key, since anyone can register
+		raw := apiKeyFromRequest(r)
+		if userID, ok := UserFromContext(r.Context()); ok && isPersonalRoute(r.URL.Path) {
+			if raw == "" {
+				if status, err := auth.AllowUser(userID); writeRateStatus(w, r, status, err) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			scope = entity.ScopeRead
+		}
+
+		key, err := auth.Authenticate(r.Context(), raw)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="market-tracker"`)
+			writeRequestError(w, r, err)
+			return
+		}
+		if !key.HasScope(scope) {
+			writeRequestError(w, r, fmt.Errorf("%w: %s", usecase.ErrForbidden, scope))
+			return
+		}
+		if status, err := auth.Allow(r.Context(), key); !writeRateStatus(w, r, status, err) {
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// writeRateStatus sets the rate limit headers and, when the request was rejected, writes
+// the error response. It reports whether the request may continue.
+func writeRateStatus(w http.ResponseWriter, r *http.Request, status usecase.RateStatus, err error) bool {
+	if status.Limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	}
+	if err != nil {
+		if status.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(status.RetryAfter.Seconds()))))
+		}
+		writeRequestError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// requiredScope returns the scope a request needs, or "" for public routes.
+func requiredScope(r *http.Request) string {
+	switch {
+	case r.URL.Path == "/health", strings.HasPrefix(r.URL.Path, "/health/"), r.URL.Path == "/metrics", strings.HasPrefix(r.URL.Path, "/api/v1/auth/"),
+		r.URL.Path == "/openapi.json", r.URL.Path == "/docs":
+		return ""
+	case r.URL.Path == "/ws":
+		return entity.ScopeStream
+	case strings.HasPrefix(r.URL.Path, "/api/v1/admin/"):
+		return entity.ScopeAdmin
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		return entity.ScopeAdmin
+	}
+	return entity.ScopeRead
+}
+
+func isPersonalRoute(path string) bool {
+	for _, p := range personalRoutes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// userTokenFromRequest returns a bearer token that is not an API key.
+func userTokenFromRequest(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && !usecase.IsAPIKey(v) {
+		return strings.TrimSpace(v)
+	}
+	return r.URL.Query().Get("token")
+}
+
+// apiKeyFromRequest returns the API key of a request; a user's access token in the
+// Authorization header is not one, so the key then comes from X-API-Key or ?api_key=.
+func apiKeyFromRequest(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && usecase.IsAPIKey(v) {
+		return strings.TrimSpace(v)
+	}
+	if v := r.Header.Get("X-API-Key"); v != "" {
+		return v
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// requireAdminKey admits requests authenticated with an admin API key, which may see the
+// resources of every user, and writes a 403 response otherwise.
+func requireAdminKey(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok || !key.HasScope(entity.ScopeAdmin) {
+		writeError(w, "admin api key required", http.StatusForbidden)
+		return 0, false
+	}
+	return usecase.AllUsers, true
+}
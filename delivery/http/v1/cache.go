@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// cached adds ETag, Last-Modified and Cache-Control headers derived from the price data
+// version to the successful responses of next, and answers conditional GET requests for
+// unchanged data with 304 Not Modified without running next. The version is read before
+// next runs, so a response racing a fetch cycle carries the older tag and is refetched.
+func (h *Handler) cached(next http.HandlerFunc) http.HandlerFunc {
+	return Cached(h.uc, next)
+}
+
+// Cached is cached for handlers outside this package that serve the data of uc.
+func Cached(uc *usecase.PriceUseCase, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		tag, modified := uc.DataVersion()
+		etag := `W/"` + tag + `"`
+		modified = modified.Truncate(time.Second) // HTTP dates have second precision
+		// Clients may reuse the response until the next fetch cycle is due
+		maxAge := int(uc.NextFetchIn() / time.Second)
+		setHeaders := func(header http.Header) {
+			header.Set("ETag", etag)
+			header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+			header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", maxAge))
+		}
+
+		if notModified(r, etag, modified) {
+			setHeaders(w.Header())
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next(&cacheWriter{ResponseWriter: w, setHeaders: setHeaders}, r)
+	}
+}
+
+// notModified evaluates If-None-Match or, without it, If-Modified-Since (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.After(t)
+	}
+	return false
+}
+
+// cacheWriter sets the caching headers when the handler responds with 200 OK; error
+// responses are sent without them.
+type cacheWriter struct {
+	http.ResponseWriter
+	setHeaders  func(http.Header)
+	wroteHeader bool
+}
+
+func (c *cacheWriter) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if code == http.StatusOK {
+			c.setHeaders(c.Header())
+		}
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *cacheWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *cacheWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressMinSize is the smallest response, by its Content-Length, worth compressing.
+// Responses without a length, such as streamed exports, are always compressed.
+const compressMinSize = 1024
+
+// encoder is implemented by the gzip and brotli writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"br": {New: func() interface{} { return brotli.NewWriterLevel(nil, 4) }},
+	"gzip": {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}},
+}
+
+// CompressMiddleware compresses text and JSON responses with brotli or gzip, whichever
+// the client prefers. Websocket upgrades and already compressed formats pass through.
+func CompressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks br or gzip from Accept-Encoding by quality, preferring br on
+// ties, or returns "" when the client accepts neither.
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "*":
+			name = "gzip"
+		case "br", "gzip":
+		default:
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether responses of the content type benefit from compression.
+func compressible(contentType string) bool {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	ct = strings.TrimSpace(ct)
+	return strings.HasPrefix(ct, "text/") || ct == "application/json" || ct == "application/xml" ||
+		ct == "application/javascript" || strings.HasSuffix(ct, "+json") || strings.HasSuffix(ct, "+xml")
+}
+
+// compressWriter decides on the first WriteHeader or Write whether to compress, based on
+// the status, content type and length the handler set.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	enc      encoder // nil when the response is sent as is
+	decided  bool
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if !c.decided {
+		c.decide(code)
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *compressWriter) decide(code int) {
+	c.decided = true
+	h := c.Header()
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified ||
+		h.Get("Content-Encoding") != "" || !compressible(h.Get("Content-Type")) {
+		return
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < compressMinSize {
+		return
+	}
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", c.encoding)
+	c.enc = encoderPools[c.encoding].Get().(encoder)
+	c.enc.Reset(c.ResponseWriter)
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	if !c.decided {
+		// Sniff the type from the plain bytes, as the server would without compression
+		if c.Header().Get("Content-Type") == "" {
+			c.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.enc == nil {
+		return c.ResponseWriter.Write(b)
+	}
+	return c.enc.Write(b)
+}
+
+// FlushError pushes the data compressed so far to the client; streaming handlers reach
+// it through http.ResponseController.
+func (c *compressWriter) FlushError() error {
+	if c.enc != nil {
+		if err := c.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+func (c *compressWriter) Flush() {
+	_ = c.FlushError()
+}
+
+func (c *compressWriter) close() {
+	if c.enc == nil {
+		return
+	}
+	_ = c.enc.Close()
+	c.enc.Reset(nil)
+	encoderPools[c.encoding].Put(c.enc)
+	c.enc = nil
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
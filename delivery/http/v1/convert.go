@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// Convert maps to /api/v1/convert
+// It converts an amount between two symbols using the stored rates, optionally at a past timestamp.
+func (h *Handler) Convert(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" || to == "" {
+		h.sendError(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	amount := 1.0
+	if v := q.Get("amount"); v != "" {
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil || a < 0 {
+			h.sendError(w, "invalid amount", http.StatusBadRequest)
+			return
+		}
+		amount = a
+	}
+
+	var at time.Time
+	if v := q.Get("at"); v != "" {
+		t, err := parseTimestamp(v)
+		if err != nil {
+			h.sendError(w, "invalid at timestamp", http.StatusBadRequest)
+			return
+		}
+		at = t
+	}
+
+	conv, err := h.uc.Convert(r.Context(), from, to, amount, at)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrUnknownSymbol) || errors.Is(err, usecase.ErrNoConversionPath) {
+			code = http.StatusNotFound
+		}
+		h.sendError(w, err.Error(), code)
+		return
+	}
+
+	response := ToConversionResponse(conv)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": response}); err != nil {
+		return
+	}
+}
+
+// ToConversionResponse maps a conversion to its client representation.
+func ToConversionResponse(conv *usecase.Conversion) dto.ConversionResponse {
+	response := dto.ConversionResponse{
+		From:   conv.From,
+		To:     conv.To,
+		Amount: conv.Amount,
+		Result: conv.Result,
+		Rate:   conv.Rate,
+		Path:   conv.Path,
+		Steps:  make([]dto.ConversionStepResponse, 0, len(conv.Steps)),
+	}
+	for _, st := range conv.Steps {
+		response.Steps = append(response.Steps, dto.ConversionStepResponse{
+			From:   st.From,
+			To:     st.To,
+			Symbol: st.Symbol,
+			Rate:   st.Rate,
+		})
+	}
+	if !conv.At.IsZero() {
+		ts := conv.At.Unix()
+		response.At = &ts
+	}
+	return response
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/export"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// exportChunkTimeout is how long the client may take to read one chunk of an export.
+// Each chunk extends the write deadline, so exports may outlast the server's WriteTimeout.
+const exportChunkTimeout = time.Minute
+
+// Export maps to GET /api/v1/export
+// It streams the history, or with kind=candles the candles, of the requested symbols as
+// csv, xlsx or parquet. names=true adds the English and Persian names and jalali=true the
+// Jalali date. Rows are read and sent in chunks, so exports of any size use little memory.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format, ok := export.ParseFormat(q.Get("format"))
+	if !ok {
+		h.sendError(w, "format must be csv, xlsx or parquet", http.StatusBadRequest)
+		return
+	}
+	from, to, err := ParseRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := usecase.ExportQuery{From: from, To: to}
+	for _, s := range strings.Split(q.Get("symbols"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			query.Symbols = append(query.Symbols, s)
+		}
+	}
+
+	kind := q.Get("kind")
+	switch kind {
+	case "", "history":
+		kind = "history"
+	case "candles":
+		interval := q.Get("interval")
+		if interval == "" {
+			interval = "1d"
+		}
+		if query.Interval, err = usecase.ParseInterval(interval); err != nil {
+			h.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		h.sendError(w, "kind must be history or candles", http.StatusBadRequest)
+		return
+	}
+	for name, opt := range map[string]*bool{"names": &query.Options.Names, "jalali": &query.Options.Jalali} {
+		if v := q.Get(name); v != "" {
+			if *opt, err = strconv.ParseBool(v); err != nil {
+				h.sendError(w, "invalid "+name+": "+v, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", kind, time.Now().In(calendar.Tehran()).Format("20060102-1504"), format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	ew, err := export.NewWriter(format, w, query.Columns())
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Nothing reaches the client before the first chunk, so earlier errors still get a JSON response
+	rc := http.NewResponseController(w)
+	started := false
+	chunk := func() error {
+		started = true
+		if err := rc.SetWriteDeadline(time.Now().Add(exportChunkTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+	rows, err := h.uc.Export(r.Context(), ew, query, chunk)
+	if err == nil {
+		started = true
+		err = ew.Close()
+	}
+	if err != nil {
+		if !started {
+			w.Header().Del("Content-Disposition")
+			h.sendError(w, err.Error(), statusFor(err))
+			return
+		}
+		// The status line is gone; abort the connection so the client sees an incomplete file
+		slog.WarnContext(r.Context(), "export aborted", "format", format, "kind", kind, "rows", rows, "err", err)
+		panic(http.ErrAbortHandler)
+	}
+	slog.DebugContext(r.Context(), "export completed", "format", format, "kind", kind, "rows", rows)
+}
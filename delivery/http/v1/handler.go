@@ -4,8 +4,11 @@ package v1
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/ar-mokhtari/market-tracker/calendar"
 	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
 	"github.com/ar-mokhtari/market-tracker/usecase"
 )
 
@@ -23,40 +26,13 @@ func NewPriceHandler(uc *usecase.PriceUseCase, h *Hub) *Handler {
 
 // sendError is a helper to return JSON error responses consistently.
 func (h *Handler) sendError(w http.ResponseWriter, message string, code int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
-		http.Error(w, message, code)
-	}
+	writeError(w, message, code)
 }
 
 // GetPrices maps to /api/v1/prices
-// It returns a list of the most recent prices converted to DTOs.
+// It returns a page of the latest prices converted to DTOs. See listPrices for the parameters.
 func (h *Handler) GetPrices(w http.ResponseWriter, r *http.Request) {
-	t := r.URL.Query().Get("type")
-	prices, err := h.uc.GetPrices(t)
-	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	response := make([]dto.PriceResponse, 0, len(prices))
-	for _, p := range prices {
-		actualPrice, _ := p.Price.Float64()
-		response = append(response, dto.PriceResponse{
-			Date:   p.Date,
-			Time:   p.Time,
-			Symbol: p.Symbol,
-			Price:  actualPrice,
-			Type:   p.Type,
-			Unit:   p.Unit,
-		})
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": response}); err != nil {
-		return
-	}
+	h.listPrices(w, r)
 }
 
 // FetchPrices maps to /api/v1/prices/fetch
@@ -65,11 +41,15 @@ func (h *Handler) GetPrices(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) FetchPrices(w http.ResponseWriter, r *http.Request) {
 	// Instead of uc.FetchFromExternal(), we just get latest prices from DB
 	// This prevents API rate limiting and high CPU usage.
-	prices, err := h.uc.GetPrices("")
+	prices, err := h.uc.GetPrices(r.Context(), "")
 	if err != nil {
 		h.sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	prices, ok := h.applyUnit(w, r, prices)
+	if !ok {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -81,31 +61,41 @@ func (h *Handler) FetchPrices(w http.ResponseWriter, r *http.Request) {
 
 // GetTimeline maps to /api/v1/prices/timeline
 // It provides a historical timeline of prices mapped to DTOs for a specific symbol.
+// Optional from/to bounds accept unix seconds, RFC 3339, or a Jalali or Gregorian date.
 func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
 	if symbol == "" {
 		h.sendError(w, "symbol is required", http.StatusBadRequest)
 		return
 	}
+	cal, ok := h.parseCalendar(w, r)
+	if !ok {
+		return
+	}
 
-	timeline, err := h.uc.GetSymbolTimeline(symbol)
+	var timeline []entity.Price
+	var err error
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, rangeErr := ParseRange(q.Get("from"), q.Get("to"))
+		if rangeErr != nil {
+			h.sendError(w, rangeErr.Error(), http.StatusBadRequest)
+			return
+		}
+		timeline, err = h.uc.GetSymbolHistory(r.Context(), symbol, from, to)
+	} else {
+		timeline, err = h.uc.GetSymbolTimeline(r.Context(), symbol)
+	}
 	if err != nil {
 		h.sendError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	response := make([]dto.PriceResponse, 0, len(timeline))
-	for _, p := range timeline {
-		actualPrice, _ := p.Price.Float64()
-		response = append(response, dto.PriceResponse{
-			Date:   p.Date,
-			Time:   p.Time,
-			Symbol: p.Symbol,
-			Price:  actualPrice,
-			Type:   p.Type,
-			Unit:   p.Unit,
-		})
+	timeline, ok = h.applyUnit(w, r, timeline)
+	if !ok {
+		return
 	}
+	response := ToPriceResponses(timeline, cal)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{"timeline": response}); err != nil {
@@ -114,33 +104,116 @@ func (h *Handler) GetTimeline(w http.ResponseWriter, r *http.Request) {
 }
 
 // ListAllPrices maps to /api/v1/prices/all
-// It retrieves all price records and maps them to DTOs for the response.
+// It retrieves the latest price records and maps them to DTOs for the response.
 func (h *Handler) ListAllPrices(w http.ResponseWriter, r *http.Request) {
-	priceType := r.URL.Query().Get("type")
+	h.listPrices(w, r)
+}
+
+// listPrices writes a page of the latest prices. type= and symbol= may be repeated or
+// comma separated, q= searches the symbol and names, sort= orders by symbol, price,
+// change_percent or updated_at (descending with a leading -), and limit=, cursor= and
+// fields= work as on every list endpoint.
+func (h *Handler) listPrices(w http.ResponseWriter, r *http.Request) {
+	cal, ok := h.parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
 
-	prices, err := h.uc.ListPrices(r.Context(), priceType)
+	page, err := h.uc.QueryPrices(r.Context(), usecase.PriceQuery{
+		Types:   queryList(r, "type"),
+		Symbols: queryList(r, "symbol"),
+		Search:  r.URL.Query().Get("q"),
+		Sort:    r.URL.Query().Get("sort"),
+		Limit:   params.Limit,
+		Cursor:  params.Cursor,
+	})
 	if err != nil {
-		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		h.sendError(w, err.Error(), statusFor(err))
+		return
+	}
+
+	prices, ok := h.applyUnit(w, r, page.Prices)
+	if !ok {
 		return
 	}
+	writeList(w, ToPriceResponses(prices, cal), page.NextCursor, params.Fields)
+}
 
+// applyUnit converts prices to the unit requested with ?unit=, if any.
+// It writes the error response itself and reports whether the handler may continue.
+func (h *Handler) applyUnit(w http.ResponseWriter, r *http.Request, prices []entity.Price) ([]entity.Price, bool) {
+	raw := r.URL.Query().Get("unit")
+	if raw == "" {
+		return prices, true
+	}
+	unit, ok := entity.ParseUnit(raw)
+	if !ok {
+		h.sendError(w, "invalid unit: "+raw, http.StatusBadRequest)
+		return nil, false
+	}
+	converted, err := h.uc.ConvertUnits(r.Context(), prices, unit)
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return converted, true
+}
+
+// parseCalendar reads ?calendar= and writes a 400 response when it is invalid.
+func (h *Handler) parseCalendar(w http.ResponseWriter, r *http.Request) (calendar.Calendar, bool) {
+	cal, ok := calendar.ParseCalendar(r.URL.Query().Get("calendar"))
+	if !ok {
+		h.sendError(w, "calendar must be jalali or gregorian", http.StatusBadRequest)
+	}
+	return cal, ok
+}
+
+// ToPriceResponses maps price entities to their client representation.
+func ToPriceResponses(prices []entity.Price, cal calendar.Calendar) []dto.PriceResponse {
 	response := make([]dto.PriceResponse, 0, len(prices))
 	for _, p := range prices {
 		actualPrice, _ := p.Price.Float64()
-		response = append(response, dto.PriceResponse{
-			Date:   p.Date,
-			Time:   p.Time,
-			Symbol: p.Symbol,
-			Price:  actualPrice,
-			Type:   p.Type,
-			Unit:   p.Unit,
-		})
-	}
+		unit := entity.Unit(entity.NormalizeUnit(p.Unit))
+		item := dto.PriceResponse{
+			Date:          p.Date,
+			Time:          p.Time,
+			TimeUnix:      p.TimeUnix,
+			Symbol:        p.Symbol,
+			NameEn:        p.NameEn,
+			NameFa:        p.NameFa,
+			Price:         actualPrice,
+			ChangePercent: p.ChangePercent,
+			Type:          p.Type,
+			Unit:          string(unit),
+			UnitEn:        unit.LabelEn(),
+			UnitFa:        unit.LabelFa(),
+		}
+		if !p.UpdatedAt.IsZero() {
+			item.UpdatedAt = p.UpdatedAt.Unix()
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": response}); err != nil {
-		return
+		// Rows written before time_unix existed only carry the provider's Jalali strings
+		ts := time.Unix(p.TimeUnix, 0)
+		if p.TimeUnix == 0 {
+			parsed, err := calendar.ParseDate(p.Date + " " + p.Time)
+			if err != nil {
+				response = append(response, item)
+				continue
+			}
+			ts = parsed
+			item.TimeUnix = ts.Unix()
+		}
+		item.DateJalali = calendar.FormatDate(ts, calendar.Jalali)
+		item.DateGregorian = calendar.FormatDate(ts, calendar.Gregorian)
+		item.Date = calendar.FormatDate(ts, cal)
+		item.Time = calendar.FormatTime(ts)
+		response = append(response, item)
 	}
+	return response
 }
 
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -148,12 +221,5 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) respond(w http.ResponseWriter, code int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	if payload != nil {
-		if err := json.NewEncoder(w).Encode(payload); err != nil {
-			// If encoding fails, fallback to basic error
-			http.Error(w, "internal server error", http.StatusInternalServerError)
-		}
-	}
+	writeJSON(w, code, payload)
 }
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type HealthHandler struct {
+	uc *usecase.HealthUseCase
+}
+
+func NewHealthHandler(uc *usecase.HealthUseCase) *HealthHandler {
+	return &HealthHandler{uc: uc}
+}
+
+func (h *HealthHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /health/live", h.Live)
+	mux.HandleFunc("GET /health/ready", h.Ready)
+}
+
+// Live maps to GET /health/live, the liveness probe. It only fails when the process cannot answer.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, h.uc.Live())
+}
+
+// Ready maps to GET /health/ready, the readiness probe. It answers 503 with per-component
+// detail when the database, schema, fetcher, provider or websocket hub is down.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, h.uc.Ready(r.Context()))
+}
+
+func writeHealth(w http.ResponseWriter, report usecase.HealthReport) {
+	res := dto.HealthResponse{
+		Status:        report.Status,
+		UptimeSeconds: int64(report.Uptime.Seconds()),
+		CheckedAt:     report.CheckedAt.Unix(),
+		Components:    make(map[string]dto.ComponentResponse, len(report.Components)),
+	}
+	for name, c := range report.Components {
+		res.Components[name] = dto.ComponentResponse{Status: c.Status, Error: c.Error, Detail: c.Detail}
+	}
+
+	code := http.StatusOK
+	if report.Status != usecase.HealthUp {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, code, res)
+}
@@ -1,10 +1,18 @@
 package v1
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
 	"github.com/gorilla/websocket"
 )
 
@@ -16,71 +24,439 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// client holds a websocket connection and its delivery preferences.
+type client struct {
+	conn    *websocket.Conn
+	send    chan interface{} // Messages waiting for writePump, closed when the client unregisters
+	unit    entity.Unit      // Empty means prices are sent as stored
+	userID  uint             // Signed-in user, 0 for anonymous connections
+	symbols map[string]bool  // Set by a subscribe message, nil means every symbol
+}
+
+// subscriber receives price broadcasts on a channel, for streams other than websockets.
+type subscriber struct {
+	ch      chan []entity.Price
+	unit    entity.Unit
+	symbols map[string]bool // nil means every symbol
+}
+
+// clientMessage is sent by clients to narrow the price stream, e.g.
+// {"action":"subscribe","watchlist_id":3} or {"action":"subscribe","symbols":["USD","BTC"]}.
+// {"action":"unsubscribe"} goes back to receiving every symbol.
+type clientMessage struct {
+	Action      string   `json:"action"`
+	WatchlistID uint     `json:"watchlist_id,omitempty"`
+	Symbols     []string `json:"symbols,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+}
+
+// priceBroadcast is queued by BroadcastPrices so each client can get its own unit.
+type priceBroadcast struct {
+	prices []entity.Price
+}
+
+// userMessage is only delivered to the connections of one user.
+type userMessage struct {
+	userID  uint
+	payload interface{}
+}
+
+// UnitConverter re-quotes prices in another unit before they are sent to a client.
+type UnitConverter func(prices []entity.Price, unit entity.Unit) ([]entity.Price, error)
+
+// WatchlistResolver returns the symbols of a user's watchlist.
+type WatchlistResolver func(ctx context.Context, id, userID uint) ([]string, error)
+
+const maxClientMessageSize = 4096
+
+// subscriberBuffer is the number of broadcasts a subscriber may fall behind before
+// updates to it are dropped.
+const subscriberBuffer = 16
+
+// clientSendBuffer is the number of messages a websocket client may fall behind before
+// messages to it are dropped; broadcastBuffer is the same for the broadcast loop.
+const (
+	clientSendBuffer = 64
+	broadcastBuffer  = 64
+)
+
 type Hub struct {
-	clients    map[*websocket.Conn]bool
+	clients    map[*websocket.Conn]*client
+	subs       map[*subscriber]bool
 	broadcast  chan interface{}
-	register   chan *websocket.Conn
+	register   chan *client
 	unregister chan *websocket.Conn
+	convert    UnitConverter
+	watchlists WatchlistResolver
 	mu         sync.Mutex
+	running    atomic.Bool
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		clients:    make(map[*websocket.Conn]bool),
-		broadcast:  make(chan interface{}),
-		register:   make(chan *websocket.Conn),
+		clients:    make(map[*websocket.Conn]*client),
+		subs:       make(map[*subscriber]bool),
+		broadcast:  make(chan interface{}, broadcastBuffer),
+		register:   make(chan *client),
 		unregister: make(chan *websocket.Conn),
 	}
 }
 
+// SetUnitConverter enables per-client unit conversion for price broadcasts.
+func (h *Hub) SetUnitConverter(fn UnitConverter) {
+	h.mu.Lock()
+	h.convert = fn
+	h.mu.Unlock()
+}
+
+// SetWatchlistResolver lets clients subscribe to a watchlist by ID.
+func (h *Hub) SetWatchlistResolver(fn WatchlistResolver) {
+	h.mu.Lock()
+	h.watchlists = fn
+	h.mu.Unlock()
+}
+
 func (h *Hub) Run() {
+	h.running.Store(true)
+	defer h.running.Store(false)
 	for {
 		select {
-		case client := <-h.register:
+		case c := <-h.register:
 			h.mu.Lock()
-			h.clients[client] = true
+			h.clients[c.conn] = c
 			h.mu.Unlock()
-			log.Println("New client registered")
+			slog.Debug("websocket client registered", "user_id", c.userID)
 
-		case client := <-h.unregister:
+		case conn := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				client.Close()
-				log.Println("Client unregistered and connection closed")
+			if c, ok := h.clients[conn]; ok {
+				delete(h.clients, conn)
+				// writePump closes the connection once the queued messages are written
+				close(c.send)
+				slog.Debug("websocket client unregistered")
 			}
 			h.mu.Unlock()
 
 		case message := <-h.broadcast:
-			h.mu.Lock()
-			// We iterate through clients and send messages
-			for client := range h.clients {
-				err := client.WriteJSON(message)
-				if err != nil {
-					log.Printf("Websocket write error: %v", err)
-					// Close and delete immediately instead of using a goroutine
-					client.Close()
-					delete(h.clients, client)
-				}
+			h.deliver(message)
+		}
+	}
+}
+
+// deliver queues a broadcast for the matching clients and subscribers. Prices are
+// converted outside h.mu and every client is written by its own writePump, so neither
+// the converter nor a slow connection holds up the hub.
+func (h *Hub) deliver(message interface{}) {
+	type target struct {
+		c       *client
+		unit    entity.Unit
+		symbols map[string]bool
+	}
+	h.mu.Lock()
+	targets := make([]target, 0, len(h.clients))
+	for _, c := range h.clients {
+		targets = append(targets, target{c: c, unit: c.unit, symbols: c.symbols})
+	}
+	subs := make([]*subscriber, 0, len(h.subs))
+	for s := range h.subs {
+		subs = append(subs, s)
+	}
+	convert := h.convert
+	h.mu.Unlock()
+
+	converted := make(map[entity.Unit][]entity.Price)
+	for _, t := range targets {
+		payload := message
+		switch m := message.(type) {
+		case priceBroadcast:
+			prices := filterSymbols(pricesIn(convert, m.prices, t.unit, converted), t.symbols)
+			if len(prices) == 0 {
+				continue
 			}
-			h.mu.Unlock()
+			payload = map[string]interface{}{"data": prices}
+		case userMessage:
+			if t.c.userID != m.userID {
+				continue
+			}
+			payload = m.payload
 		}
+		t.c.queue(payload)
+	}
+	if m, ok := message.(priceBroadcast); ok {
+		h.publish(subs, convert, m.prices, converted)
 	}
 }
 
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("Upgrade failed: %v", err)
+		slog.WarnContext(r.Context(), "websocket upgrade failed", "err", err)
 		return
 	}
-	h.register <- conn
+	c := &client{conn: conn, send: make(chan interface{}, clientSendBuffer)}
+	c.userID, _ = UserFromContext(r.Context())
+	if raw := r.URL.Query().Get("unit"); raw != "" {
+		if u, ok := entity.ParseUnit(raw); ok {
+			c.unit = u
+		}
+	}
+	go c.writePump()
+	h.register <- c
+
+	conn.SetReadLimit(maxClientMessageSize)
+	for {
+		var msg clientMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				h.reply(c, "error", map[string]string{"message": "invalid message"})
+				continue
+			}
+			h.unregister <- conn
+			return
+		}
+		h.handleMessage(r.Context(), c, msg)
+	}
+}
+
+// handleMessage applies a subscribe or unsubscribe message from a client.
+func (h *Hub) handleMessage(ctx context.Context, c *client, msg clientMessage) {
+	var unit entity.Unit
+	if msg.Unit != "" {
+		u, ok := entity.ParseUnit(msg.Unit)
+		if !ok {
+			h.reply(c, "error", map[string]string{"message": "invalid unit: " + msg.Unit})
+			return
+		}
+		unit = u
+	}
+
+	switch msg.Action {
+	case "subscribe":
+		symbols := msg.Symbols
+		if msg.WatchlistID != 0 {
+			h.mu.Lock()
+			resolve := h.watchlists
+			h.mu.Unlock()
+			if c.userID == 0 || resolve == nil {
+				h.reply(c, "error", map[string]string{"message": "sign in to subscribe to a watchlist"})
+				return
+			}
+			var err error
+			if symbols, err = resolve(ctx, msg.WatchlistID, c.userID); err != nil {
+				h.reply(c, "error", map[string]string{"message": err.Error()})
+				return
+			}
+		}
+		if len(symbols) == 0 {
+			h.reply(c, "error", map[string]string{"message": "watchlist_id or symbols is required"})
+			return
+		}
+
+		set := make(map[string]bool, len(symbols))
+		for i, s := range symbols {
+			symbols[i] = strings.ToUpper(strings.TrimSpace(s))
+			set[symbols[i]] = true
+		}
+		h.mu.Lock()
+		c.symbols = set
+		if unit != "" {
+			c.unit = unit
+		}
+		h.mu.Unlock()
+		h.reply(c, "subscribed", map[string]interface{}{"watchlist_id": msg.WatchlistID, "symbols": symbols})
+
+	case "unsubscribe":
+		h.mu.Lock()
+		c.symbols = nil
+		h.mu.Unlock()
+		h.reply(c, "unsubscribed", nil)
+
+	default:
+		h.reply(c, "error", map[string]string{"message": "unknown action: " + msg.Action})
+	}
+}
+
+// reply queues a message for one client, behind the broadcasts already queued for it.
+func (h *Hub) reply(c *client, msgType string, data interface{}) {
+	c.queue(map[string]interface{}{"type": msgType, "data": data})
+}
+
+// queue hands a message to the client's writePump, dropping it when the client is too far behind.
+func (c *client) queue(msg interface{}) {
+	select {
+	case c.send <- msg:
+	default:
+		slog.Warn("websocket client is behind, skipping message")
+	}
+}
+
+// writePump is the only writer of a connection. A failed write closes the connection,
+// which ends the read loop of ServeWS and unregisters the client.
+func (c *client) writePump() {
+	defer c.conn.Close()
+	for msg := range c.send {
+		if err := c.conn.WriteJSON(msg); err != nil {
+			slog.Warn("websocket write failed", "err", err)
+			return
+		}
+	}
+}
+
+// filterSymbols keeps the prices of the given symbols; a nil set keeps every price.
+func filterSymbols(prices []entity.Price, symbols map[string]bool) []entity.Price {
+	if symbols == nil {
+		return prices
+	}
+	out := make([]entity.Price, 0, len(symbols))
+	for _, p := range prices {
+		if symbols[p.Symbol] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Subscribe receives the price broadcasts the websocket clients get, converted to unit
+// (empty for prices as stored) and narrowed to symbols (every symbol when empty). The
+// channel is buffered; broadcasts are dropped for a subscriber that falls behind. Call
+// the returned function to unsubscribe, which closes the channel.
+func (h *Hub) Subscribe(symbols []string, unit entity.Unit) (<-chan []entity.Price, func()) {
+	s := &subscriber{ch: make(chan []entity.Price, subscriberBuffer), unit: unit}
+	if len(symbols) > 0 {
+		s.symbols = make(map[string]bool, len(symbols))
+		for _, sym := range symbols {
+			s.symbols[strings.ToUpper(strings.TrimSpace(sym))] = true
+		}
+	}
+	h.mu.Lock()
+	h.subs[s] = true
+	h.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, s)
+			close(s.ch)
+			h.mu.Unlock()
+		})
+	}
+}
+
+// publish hands a price broadcast to the subscribers. Only the send holds h.mu, which
+// keeps it from racing an unsubscribe that closes the channel.
+func (h *Hub) publish(subs []*subscriber, convert UnitConverter, prices []entity.Price, converted map[entity.Unit][]entity.Price) {
+	for _, s := range subs {
+		out := filterSymbols(pricesIn(convert, prices, s.unit, converted), s.symbols)
+		if len(out) == 0 {
+			continue
+		}
+		h.mu.Lock()
+		if h.subs[s] {
+			select {
+			case s.ch <- out:
+			default:
+				slog.Warn("price subscriber is behind, skipping update")
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// SubscriberCount returns the number of channel subscribers.
+func (h *Hub) SubscriberCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
 }
 
 func (h *Hub) BroadcastUpdate(data interface{}) {
 	select {
 	case h.broadcast <- data:
 	default:
-		log.Println("Broadcast channel is full, skipping update")
+		slog.Warn("broadcast channel is full, skipping update")
+	}
+}
+
+// BroadcastPrices sends price updates to every client in the unit it asked for.
+func (h *Hub) BroadcastPrices(prices []entity.Price) {
+	h.BroadcastUpdate(priceBroadcast{prices: prices})
+}
+
+// pricesIn converts prices to one unit, at most once per unit per broadcast.
+func pricesIn(convert UnitConverter, prices []entity.Price, unit entity.Unit, cache map[entity.Unit][]entity.Price) []entity.Price {
+	if p, ok := cache[unit]; ok {
+		return p
+	}
+	out := prices
+	if unit != "" && convert != nil {
+		converted, err := convert(prices, unit)
+		if err != nil {
+			slog.Error("websocket unit conversion failed", "unit", unit, "err", err)
+		} else {
+			out = converted
+		}
+	}
+	cache[unit] = out
+	return out
+}
+
+// Running reports whether the broadcast loop has been started.
+func (h *Hub) Running() bool {
+	return h.running.Load()
+}
+
+// ClientCount returns the number of open websocket connections.
+func (h *Hub) ClientCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// ConnectedUsers returns the IDs of the signed-in users with at least one open connection.
+func (h *Hub) ConnectedUsers() []uint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seen := make(map[uint]bool)
+	var users []uint
+	for _, c := range h.clients {
+		if c.userID != 0 && !seen[c.userID] {
+			seen[c.userID] = true
+			users = append(users, c.userID)
+		}
+	}
+	return users
+}
+
+// SendToUser pushes a typed message to every connection of one user.
+func (h *Hub) SendToUser(userID uint, msgType string, data interface{}) {
+	h.BroadcastUpdate(userMessage{userID: userID, payload: map[string]interface{}{"type": msgType, "data": data}})
+}
+
+// NotifyPortfolios pushes live portfolio valuations to their owners.
+func (h *Hub) NotifyPortfolios(valuations map[uint][]usecase.PortfolioValuation) {
+	for userID, list := range valuations {
+		data := make([]dto.PortfolioValuationResponse, 0, len(list))
+		for _, v := range list {
+			data = append(data, toValuationResponse(v))
+		}
+		h.SendToUser(userID, "portfolio", data)
+	}
+}
+
+// NotifyAlert pushes a triggered alert to every connected client, or only to its owner's connections.
+// Alerts created from a Telegram chat are only delivered by the bot.
+func (h *Hub) NotifyAlert(_ context.Context, event entity.AlertEvent) error {
+	if event.ChatID != 0 {
+		return nil
+	}
+	msg := map[string]interface{}{"type": "alert", "data": event}
+	if event.UserID != 0 {
+		h.BroadcastUpdate(userMessage{userID: event.UserID, payload: msg})
+		return nil
 	}
+	h.BroadcastUpdate(msg)
+	return nil
 }
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// GetIndicator maps to /api/v1/indicators
+// It computes sma, ema, rsi, bollinger or volatility over candles of a symbol.
+func (h *Handler) GetIndicator(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	if symbol == "" {
+		h.sendError(w, "symbol is required", http.StatusBadRequest)
+		return
+	}
+	cal, ok := h.parseCalendar(w, r)
+	if !ok {
+		return
+	}
+
+	period := 14
+	if v := q.Get("period"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			h.sendError(w, "invalid period", http.StatusBadRequest)
+			return
+		}
+		period = p
+	}
+
+	interval, err := usecase.ParseInterval(q.Get("interval"))
+	if err != nil {
+		h.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := usecase.IndicatorQuery{
+		Symbol:   symbol,
+		Type:     q.Get("type"),
+		Period:   period,
+		Interval: interval,
+	}
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, err := ParseRange(q.Get("from"), q.Get("to"))
+		if err != nil {
+			h.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if q.Get("from") != "" {
+			query.From = from
+		}
+		query.To = to
+	}
+
+	points, err := h.uc.GetIndicator(r.Context(), query)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidIndicator) || errors.Is(err, usecase.ErrInvalidInterval) {
+			code = http.StatusBadRequest
+		}
+		h.sendError(w, err.Error(), code)
+		return
+	}
+
+	response := dto.IndicatorResponse{
+		Symbol:   strings.ToUpper(symbol),
+		Type:     strings.ToLower(query.Type),
+		Period:   period,
+		Interval: q.Get("interval"),
+		Points:   ToIndicatorPoints(points, cal),
+	}
+	if response.Interval == "" {
+		response.Interval = "1h"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": response}); err != nil {
+		return
+	}
+}
+
+// ToIndicatorPoints maps indicator values to their client representation.
+func ToIndicatorPoints(points []usecase.IndicatorPoint, cal calendar.Calendar) []dto.IndicatorPointResponse {
+	response := make([]dto.IndicatorPointResponse, 0, len(points))
+	for _, p := range points {
+		ts := time.Unix(p.TimeUnix, 0)
+		response = append(response, dto.IndicatorPointResponse{
+			TimeUnix: p.TimeUnix,
+			Date:     calendar.FormatDate(ts, cal),
+			Time:     calendar.FormatTime(ts),
+			Value:    p.Value,
+			Upper:    p.Upper,
+			Lower:    p.Lower,
+		})
+	}
+	return response
+}
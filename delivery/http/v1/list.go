@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// listParams are the query parameters every list endpoint accepts: ?limit= and ?cursor=
+// page the list and ?fields= keeps only the named fields of each item.
+type listParams struct {
+	Limit  int
+	Cursor string
+	Fields []string
+}
+
+// parseList reads the list parameters and writes a 400 response when they are invalid.
+func parseList(w http.ResponseWriter, r *http.Request) (listParams, bool) {
+	q := r.URL.Query()
+	p := listParams{Cursor: q.Get("cursor"), Fields: queryList(r, "fields")}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > usecase.MaxPageSize {
+			writeError(w, fmt.Sprintf("limit must be between 1 and %d", usecase.MaxPageSize), http.StatusBadRequest)
+			return p, false
+		}
+		p.Limit = n
+	}
+	return p, true
+}
+
+// queryList returns the values of a parameter that may be repeated or comma separated,
+// so type=gold&type=currency and type=gold,currency are the same.
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, v := range r.URL.Query()[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// listKey is the position of an item in a list ordered by time, when the list has one,
+// and then by id. It is also the content of the list cursors.
+type listKey struct {
+	At int64 `json:"t,omitempty"`
+	ID uint  `json:"id"`
+}
+
+func (k listKey) after(o listKey) bool {
+	return k.At > o.At || (k.At == o.At && k.ID > o.ID)
+}
+
+// idKey is the key of lists ordered by id alone.
+func idKey(id uint) listKey {
+	return listKey{ID: id}
+}
+
+// pageAfter returns the page of items, ordered by key ascending or, with desc, descending,
+// that follows p.Cursor, and the cursor of the next page, if any.
+func pageAfter[T any](items []T, key func(T) listKey, desc bool, p listParams) ([]T, string, error) {
+	limit := p.Limit
+	if limit == 0 {
+		limit = usecase.DefaultPageSize
+	}
+	if p.Cursor != "" {
+		var last listKey
+		if err := usecase.DecodeCursor(p.Cursor, &last); err != nil {
+			return nil, "", err
+		}
+		start := len(items)
+		for i, item := range items {
+			if k := key(item); (!desc && k.after(last)) || (desc && last.after(k)) {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	}
+	if len(items) <= limit {
+		return items, "", nil
+	}
+	items = items[:limit]
+	return items, usecase.EncodeCursor(key(items[limit-1])), nil
+}
+
+// writeList writes {"data": items} and, when another page follows, its cursor. With
+// fields, each item only keeps those fields; they are checked against the item type
+// so unknown names are rejected even for empty lists.
+func writeList(w http.ResponseWriter, items interface{}, next string, fields []string) {
+	payload := map[string]interface{}{"data": items}
+	if next != "" {
+		payload["next_cursor"] = next
+	}
+	if len(fields) == 0 {
+		writeJSON(w, http.StatusOK, payload)
+		return
+	}
+
+	known := jsonFields(reflect.TypeOf(items).Elem())
+	for _, f := range fields {
+		if !known[f] {
+			writeError(w, "unknown field: "+f, http.StatusBadRequest)
+			return
+		}
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var full []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &full); err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sparse := make([]map[string]json.RawMessage, len(full))
+	for i, item := range full {
+		sparse[i] = make(map[string]json.RawMessage, len(fields))
+		for _, f := range fields {
+			if v, ok := item[f]; ok {
+				sparse[i][f] = v
+			}
+		}
+	}
+	payload["data"] = sparse
+	writeJSON(w, http.StatusOK, payload)
+}
+
+// jsonFields returns the JSON names of the fields of a struct type, including embedded ones.
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !f.IsExported():
+		case f.Anonymous && name == "":
+			for n := range jsonFields(f.Type) {
+				fields[n] = true
+			}
+		case name == "":
+			fields[f.Name] = true
+		default:
+			fields[name] = true
+		}
+	}
+	return fields
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+)
+
+// parseTimestamp accepts unix seconds, an RFC 3339 timestamp, or a Jalali or Gregorian date.
+func parseTimestamp(v string) (time.Time, error) {
+	return calendar.ParseTimestamp(v)
+}
+
+// ParseRange parses optional from/to bounds. A bare date as the upper bound covers the whole day.
+func ParseRange(fromRaw, toRaw string) (time.Time, time.Time, error) {
+	from, to := time.Unix(0, 0), time.Now()
+	if fromRaw != "" {
+		t, err := parseTimestamp(fromRaw)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from: %w", err)
+		}
+		from = t
+	}
+	if toRaw != "" {
+		t, err := parseTimestamp(toRaw)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to: %w", err)
+		}
+		if isDateOnly(toRaw) {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		to = t
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+// isDateOnly reports whether v is a date without a time of day. Unix seconds, negative
+// ones included, are instants even though they may contain a '-'.
+func isDateOnly(v string) bool {
+	v = strings.TrimSpace(v)
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return false
+	}
+	return !strings.ContainsAny(v, " T:") && strings.ContainsAny(v, "/-")
+}
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+)
+
+func TestParseRangeUpperBound(t *testing.T) {
+	day := time.Date(2025, 12, 20, 0, 0, 0, 0, calendar.Tehran())
+	tests := []struct {
+		name string
+		to   string
+		want time.Time
+	}{
+		{"gregorian date covers the day", "2025-12-20", day.AddDate(0, 0, 1).Add(-time.Second)},
+		{"jalali date covers the day", "1404/09/29", day.AddDate(0, 0, 1).Add(-time.Second)},
+		{"unix seconds", "1766188800", time.Unix(1766188800, 0)},
+		{"negative unix seconds", "-86400", time.Unix(-86400, 0)},
+		{"date with time", "2025-12-20 10:30", day.Add(10*time.Hour + 30*time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, to, err := ParseRange("-172800", tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !to.Equal(tt.want) {
+				t.Errorf("to = %v, want %v", to, tt.want)
+			}
+		})
+	}
+}
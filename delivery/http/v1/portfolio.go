@@ -0,0 +1,304 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type PortfolioHandler struct {
+	uc *usecase.PortfolioUseCase
+}
+
+func NewPortfolioHandler(uc *usecase.PortfolioUseCase) *PortfolioHandler {
+	return &PortfolioHandler{uc: uc}
+}
+
+func (h *PortfolioHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /api/v1/portfolios", h.List)
+	mux.HandleFunc("POST /api/v1/portfolios", h.Create)
+	mux.HandleFunc("GET /api/v1/portfolios/{id}", h.Valuation)
+	mux.HandleFunc("DELETE /api/v1/portfolios/{id}", h.Delete)
+	mux.HandleFunc("GET /api/v1/portfolios/{id}/history", h.History)
+	mux.HandleFunc("GET /api/v1/portfolios/{id}/transactions", h.Transactions)
+	mux.HandleFunc("POST /api/v1/portfolios/{id}/transactions", h.AddTransaction)
+	mux.HandleFunc("DELETE /api/v1/portfolios/{id}/transactions/{tx}", h.DeleteTransaction)
+}
+
+// List maps to GET /api/v1/portfolios
+func (h *PortfolioHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	portfolios, err := h.uc.List(r.Context(), uid)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if portfolios == nil {
+		portfolios = []entity.Portfolio{}
+	}
+	page, next, err := pageAfter(portfolios, func(v entity.Portfolio) listKey { return idKey(v.ID) }, false, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// Create maps to POST /api/v1/portfolios
+func (h *PortfolioHandler) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	var req dto.PortfolioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	pf := entity.Portfolio{UserID: uid, Name: req.Name}
+	if err := h.uc.Create(r.Context(), &pf); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": pf})
+}
+
+// Valuation maps to GET /api/v1/portfolios/{id}
+// It returns the holdings valued at the latest prices with P&L and allocation by type.
+func (h *PortfolioHandler) Valuation(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	v, err := h.uc.Valuation(r.Context(), id, uid)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": toValuationResponse(v)})
+}
+
+// Delete maps to DELETE /api/v1/portfolios/{id}
+func (h *PortfolioHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Delete(r.Context(), id, uid); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// History maps to GET /api/v1/portfolios/{id}/history?from=&to=&interval=1d
+// The portfolio is valued at each point with the prices recorded in price_history.
+func (h *PortfolioHandler) History(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	cal, ok := calendar.ParseCalendar(q.Get("calendar"))
+	if !ok {
+		writeError(w, "calendar must be jalali or gregorian", http.StatusBadRequest)
+		return
+	}
+	rawInterval := q.Get("interval")
+	if rawInterval == "" {
+		rawInterval = "1d"
+	}
+	interval, err := usecase.ParseInterval(rawInterval)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var from, to time.Time
+	if q.Get("from") != "" || q.Get("to") != "" {
+		if from, to, err = ParseRange(q.Get("from"), q.Get("to")); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if q.Get("from") == "" {
+			from = time.Time{}
+		}
+	}
+
+	points, err := h.uc.History(r.Context(), id, uid, from, to, interval)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	response := make([]dto.PortfolioPointResponse, 0, len(points))
+	for _, p := range points {
+		ts := time.Unix(p.TimeUnix, 0)
+		response = append(response, dto.PortfolioPointResponse{
+			TimeUnix: p.TimeUnix,
+			Date:     calendar.FormatDate(ts, cal),
+			Time:     calendar.FormatTime(ts),
+			ValueIRT: p.ValueIRT,
+			ValueUSD: p.ValueUSD,
+			CostIRT:  p.CostIRT,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": response})
+}
+
+// Transactions maps to GET /api/v1/portfolios/{id}/transactions
+func (h *PortfolioHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	txs, err := h.uc.Transactions(r.Context(), id, uid)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if txs == nil {
+		txs = []entity.PortfolioTransaction{}
+	}
+	page, next, err := pageAfter(txs, func(v entity.PortfolioTransaction) listKey { return listKey{v.ExecutedAt.Unix(), v.ID} }, false, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// AddTransaction maps to POST /api/v1/portfolios/{id}/transactions
+func (h *PortfolioHandler) AddTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	var req dto.TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	t := entity.PortfolioTransaction{
+		PortfolioID: id,
+		Symbol:      req.Symbol,
+		Side:        req.Side,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Unit:        req.Unit,
+		Note:        req.Note,
+	}
+	if req.ExecutedAt != "" {
+		at, err := parseTimestamp(req.ExecutedAt)
+		if err != nil {
+			writeError(w, "invalid executed_at: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		t.ExecutedAt = at
+	}
+	if err := h.uc.AddTransaction(r.Context(), uid, &t); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": t})
+}
+
+// DeleteTransaction maps to DELETE /api/v1/portfolios/{id}/transactions/{tx}
+func (h *PortfolioHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	txID, err := strconv.ParseUint(r.PathValue("tx"), 10, 64)
+	if !ok || err != nil || txID == 0 {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.DeleteTransaction(r.Context(), id, uint(txID), uid); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toValuationResponse(v usecase.PortfolioValuation) dto.PortfolioValuationResponse {
+	res := dto.PortfolioValuationResponse{
+		ID:            v.Portfolio.ID,
+		Name:          v.Portfolio.Name,
+		ValueIRT:      v.ValueIRT,
+		ValueUSD:      v.ValueUSD,
+		CostIRT:       v.CostIRT,
+		CostUSD:       v.CostUSD,
+		UnrealizedIRT: v.UnrealizedIRT,
+		UnrealizedUSD: v.UnrealizedUSD,
+		RealizedIRT:   v.RealizedIRT,
+		RealizedUSD:   v.RealizedUSD,
+		PnLPercent:    v.PnLPercent,
+		Holdings:      make([]dto.HoldingResponse, 0, len(v.Holdings)),
+		Allocation:    make([]dto.AllocationResponse, 0, len(v.Allocation)),
+		AsOf:          v.AsOf.Unix(),
+	}
+	for _, h := range v.Holdings {
+		res.Holdings = append(res.Holdings, dto.HoldingResponse{
+			Symbol:     h.Symbol,
+			Type:       h.Type,
+			Quantity:   h.Quantity,
+			AvgCostIRT: h.AvgCostIRT,
+			CostIRT:    h.CostIRT,
+			CostUSD:    h.CostUSD,
+			PriceIRT:   h.PriceIRT,
+			ValueIRT:   h.ValueIRT,
+			ValueUSD:   h.ValueUSD,
+			PnLIRT:     h.PnLIRT,
+			PnLUSD:     h.PnLUSD,
+			PnLPercent: h.PnLPercent,
+			Priced:     h.Priced,
+		})
+	}
+	for _, a := range v.Allocation {
+		res.Allocation = append(res.Allocation, dto.AllocationResponse{Type: a.Type, ValueIRT: a.ValueIRT, Percent: a.Percent})
+	}
+	return res
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller supplied IDs to short, log-safe values.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware tags every request with an ID, taken from X-Request-ID or generated,
+// echoes it in the response and stores it in the request context so every log record
+// down to the repository calls carries it. Each request is logged once it completes.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = logger.NewID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := logger.WithRequestID(r.Context(), id)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+
+		rec := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		switch {
+		case rec.code >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case strings.HasPrefix(r.URL.Path, "/health") || r.URL.Path == "/metrics":
+			level = slog.LevelDebug
+		}
+		slog.Log(ctx, level, "http request", "method", r.Method, "path", r.URL.Path, "status", rec.code,
+			"duration_ms", time.Since(start).Milliseconds(), "remote", r.RemoteAddr)
+	})
+}
+
+// responseRecorder captures the status code written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.code = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack hands the connection over to the websocket upgrader.
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+	rec.code = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/delivery/http/problem"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// writeError returns a JSON error response, shared by every handler in this package.
+func writeError(w http.ResponseWriter, message string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		http.Error(w, message, code)
+	}
+}
+
+// writeRequestError writes an error of the middlewares, as problem details for /api/v2
+// requests and in the v1 format otherwise.
+func writeRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	status := statusFor(err)
+	if !strings.HasPrefix(r.URL.Path, "/api/v2/") {
+		writeError(w, err.Error(), status)
+		return
+	}
+	code := problem.CodeFor(status)
+	if errors.Is(err, usecase.ErrQuotaExceeded) {
+		code = problem.CodeQuotaExceeded
+	}
+	problem.Write(w, r, status, code, err.Error())
+}
+
+// writeJSON encodes payload with the given status code.
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if payload != nil {
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			// If encoding fails, fallback to basic error
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// statusFor maps domain errors to HTTP status codes.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, entity.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, usecase.ErrInvalidAlert), errors.Is(err, usecase.ErrInvalidWebhook), errors.Is(err, usecase.ErrInvalidAPIKey),
+		errors.Is(err, usecase.ErrInvalidUser), errors.Is(err, usecase.ErrInvalidWatchlist),
+		errors.Is(err, usecase.ErrInvalidPortfolio), errors.Is(err, usecase.ErrInvalidTransaction), errors.Is(err, usecase.ErrInvalidInterval),
+		errors.Is(err, usecase.ErrInvalidQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, usecase.ErrUnauthorized), errors.Is(err, usecase.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, usecase.ErrEmailTaken), errors.Is(err, entity.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, usecase.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, usecase.ErrRateLimited), errors.Is(err, usecase.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// pathID reads a numeric {id} path segment.
+func pathID(r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	return uint(id), err == nil && id > 0
+}
@@ -2,11 +2,47 @@ package v1
 
 import "net/http"
 
-func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/v1/prices", h.GetPrices)
-	mux.HandleFunc("/api/v1/prices/fetch", h.FetchPrices)
-	mux.HandleFunc("/api/v1/prices/timeline", h.GetTimeline)
-	mux.HandleFunc("/api/v1/prices/all", h.ListAllPrices)
+// Mux is where handlers register their routes; *http.ServeMux implements it.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// RouteRecorder registers routes on a Mux and remembers their patterns, so the served
+// routes can be compared with the API documentation.
+type RouteRecorder struct {
+	Mux
+	patterns []string
+}
+
+func NewRouteRecorder(mux Mux) *RouteRecorder {
+	return &RouteRecorder{Mux: mux}
+}
+
+func (r *RouteRecorder) Handle(pattern string, handler http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+	r.Mux.Handle(pattern, handler)
+}
+
+func (r *RouteRecorder) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.patterns = append(r.patterns, pattern)
+	r.Mux.HandleFunc(pattern, handler)
+}
+
+// Patterns returns the patterns registered so far, in registration order.
+func (r *RouteRecorder) Patterns() []string {
+	return r.patterns
+}
+
+func (h *Handler) RegisterRoutes(mux Mux) {
+	// Responses that only depend on the stored prices support conditional requests
+	mux.HandleFunc("/api/v1/prices", h.cached(h.GetPrices))
+	mux.HandleFunc("/api/v1/prices/fetch", h.cached(h.FetchPrices))
+	mux.HandleFunc("/api/v1/prices/timeline", h.cached(h.GetTimeline))
+	mux.HandleFunc("/api/v1/prices/all", h.cached(h.ListAllPrices))
+	mux.HandleFunc("/api/v1/convert", h.cached(h.Convert))
+	mux.HandleFunc("/api/v1/indicators", h.cached(h.GetIndicator))
+	mux.HandleFunc("GET /api/v1/export", h.Export)
 
 	// Add WebSocket endpoint
 	mux.HandleFunc("/ws", h.hub.ServeWS)
@@ -0,0 +1,99 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type UserHandler struct {
+	uc *usecase.UserUseCase
+}
+
+func NewUserHandler(uc *usecase.UserUseCase) *UserHandler {
+	return &UserHandler{uc: uc}
+}
+
+func (h *UserHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("POST /api/v1/auth/register", h.Register)
+	mux.HandleFunc("POST /api/v1/auth/login", h.Login)
+	mux.HandleFunc("POST /api/v1/auth/refresh", h.Refresh)
+	mux.HandleFunc("POST /api/v1/auth/logout", h.Logout)
+	mux.HandleFunc("GET /api/v1/me", h.Me)
+}
+
+// Register maps to POST /api/v1/auth/register
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req dto.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := h.uc.Register(r.Context(), req.Email, req.Password, req.Name)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": user})
+}
+
+// Login maps to POST /api/v1/auth/login
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req dto.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	tokens, err := h.uc.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": tokens})
+}
+
+// Refresh maps to POST /api/v1/auth/refresh
+// The old refresh token is consumed and a new pair is returned.
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+	tokens, err := h.uc.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": tokens})
+}
+
+// Logout maps to POST /api/v1/auth/logout
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeError(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me maps to GET /api/v1/me
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	id, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.uc.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": user})
+}
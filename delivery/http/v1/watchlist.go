@@ -0,0 +1,191 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type WatchlistHandler struct {
+	uc *usecase.WatchlistUseCase
+}
+
+func NewWatchlistHandler(uc *usecase.WatchlistUseCase) *WatchlistHandler {
+	return &WatchlistHandler{uc: uc}
+}
+
+func (h *WatchlistHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /api/v1/watchlists", h.List)
+	mux.HandleFunc("POST /api/v1/watchlists", h.Create)
+	mux.HandleFunc("GET /api/v1/watchlists/{id}", h.Get)
+	mux.HandleFunc("PUT /api/v1/watchlists/{id}", h.Update)
+	mux.HandleFunc("DELETE /api/v1/watchlists/{id}", h.Delete)
+	mux.HandleFunc("GET /api/v1/watchlists/{id}/quotes", h.Quotes)
+}
+
+// List maps to GET /api/v1/watchlists
+func (h *WatchlistHandler) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	lists, err := h.uc.List(r.Context(), uid)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if lists == nil {
+		lists = []entity.Watchlist{}
+	}
+	page, next, err := pageAfter(lists, func(v entity.Watchlist) listKey { return idKey(v.ID) }, false, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// Create maps to POST /api/v1/watchlists
+func (h *WatchlistHandler) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	var req dto.WatchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wl := entity.Watchlist{UserID: uid, Name: req.Name, Symbols: req.Symbols}
+	if err := h.uc.Create(r.Context(), &wl); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": wl})
+}
+
+// Get maps to GET /api/v1/watchlists/{id}
+func (h *WatchlistHandler) Get(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.uc.Get(r.Context(), id, uid)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": wl})
+}
+
+// Update maps to PUT /api/v1/watchlists/{id}
+func (h *WatchlistHandler) Update(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	var req dto.WatchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wl := entity.Watchlist{ID: id, UserID: uid, Name: req.Name, Symbols: req.Symbols}
+	if err := h.uc.Update(r.Context(), &wl); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": wl})
+}
+
+// Delete maps to DELETE /api/v1/watchlists/{id}
+func (h *WatchlistHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Delete(r.Context(), id, uid); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Quotes maps to GET /api/v1/watchlists/{id}/quotes?unit=USD&calendar=gregorian
+func (h *WatchlistHandler) Quotes(w http.ResponseWriter, r *http.Request) {
+	uid, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	cal, ok := calendar.ParseCalendar(q.Get("calendar"))
+	if !ok {
+		writeError(w, "calendar must be jalali or gregorian", http.StatusBadRequest)
+		return
+	}
+	var unit entity.Unit
+	if raw := q.Get("unit"); raw != "" {
+		if unit, ok = entity.ParseUnit(raw); !ok {
+			writeError(w, "invalid unit: "+raw, http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.uc.Quotes(r.Context(), id, uid, unit)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": dto.WatchlistQuotesResponse{
+		ID:      result.Watchlist.ID,
+		Name:    result.Watchlist.Name,
+		Quotes:  toQuoteResponses(result.Quotes, cal),
+		Missing: result.Missing,
+	}})
+}
+
+// toQuoteResponses adds names and changes to the price representation.
+func toQuoteResponses(prices []entity.Price, cal calendar.Calendar) []dto.QuoteResponse {
+	base := ToPriceResponses(prices, cal)
+	quotes := make([]dto.QuoteResponse, len(prices))
+	for i, p := range prices {
+		change, _ := p.ChangeValue.Float64()
+		quotes[i] = dto.QuoteResponse{
+			PriceResponse: base[i],
+			NameEn:        p.NameEn,
+			NameFa:        p.NameFa,
+			ChangeValue:   change,
+			ChangePercent: p.ChangePercent,
+		}
+	}
+	return quotes
+}
@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+type WebhookHandler struct {
+	uc *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(uc *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{uc: uc}
+}
+
+// RegisterRoutes registers the webhook routes. Webhooks receive the alerts of every user and
+// their delivery log keeps the payloads, so every route needs an admin key.
+func (h *WebhookHandler) RegisterRoutes(mux Mux) {
+	mux.HandleFunc("GET /api/v1/webhooks", h.List)
+	mux.HandleFunc("POST /api/v1/webhooks", h.Create)
+	mux.HandleFunc("GET /api/v1/webhooks/{id}", h.Get)
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", h.Delete)
+	mux.HandleFunc("GET /api/v1/webhooks/{id}/deliveries", h.Deliveries)
+	mux.HandleFunc("POST /api/v1/webhooks/deliveries/{id}/retry", h.Redeliver)
+}
+
+// List maps to GET /api/v1/webhooks
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	hooks, err := h.uc.List(r.Context())
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if hooks == nil {
+		hooks = []entity.Webhook{}
+	}
+	page, next, err := pageAfter(hooks, func(v entity.Webhook) listKey { return idKey(v.ID) }, false, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// Create maps to POST /api/v1/webhooks
+// The response is the only place the signing secret is returned.
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	var req dto.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	wh := entity.Webhook{
+		URL:      req.URL,
+		Secret:   req.Secret,
+		Events:   req.Events,
+		Symbols:  req.Symbols,
+		Types:    req.Types,
+		AlertIDs: req.AlertIDs,
+	}
+	if err := h.uc.Create(r.Context(), &wh); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": wh})
+}
+
+// Get maps to GET /api/v1/webhooks/{id}
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	wh, err := h.uc.Get(r.Context(), id)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": wh})
+}
+
+// Delete maps to DELETE /api/v1/webhooks/{id}
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Delete(r.Context(), id); err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Deliveries maps to GET /api/v1/webhooks/{id}/deliveries
+// Use ?status=dead to list the dead letters.
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	params, ok := parseList(w, r)
+	if !ok {
+		return
+	}
+	deliveries, err := h.uc.Deliveries(r.Context(), id, r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	if deliveries == nil {
+		deliveries = []entity.WebhookDelivery{}
+	}
+	page, next, err := pageAfter(deliveries, func(v entity.WebhookDelivery) listKey { return idKey(v.ID) }, true, params)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeList(w, page, next, params.Fields)
+}
+
+// Redeliver maps to POST /api/v1/webhooks/deliveries/{id}/retry
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminKey(w, r); !ok {
+		return
+	}
+	id, ok := pathID(r)
+	if !ok {
+		writeError(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	d, err := h.uc.Redeliver(r.Context(), id)
+	if err != nil {
+		writeError(w, err.Error(), statusFor(err))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"data": d})
+}
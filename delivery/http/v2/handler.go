@@ -0,0 +1,334 @@
+// Package v2 provides the second version of the HTTP API. Every response uses one
+// envelope, {"data": ..., "meta": {...}}, and errors are RFC 7807 problem details with
+// a typed code. The v1 routes keep their formats and are served alongside.
+package v2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/delivery/http/problem"
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	"github.com/ar-mokhtari/market-tracker/dto"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// defaultCandleDays is the range of candles requested without ?from=.
+const defaultCandleDays = 30
+
+type Handler struct {
+	uc *usecase.PriceUseCase
+}
+
+func NewHandler(uc *usecase.PriceUseCase) *Handler {
+	return &Handler{uc: uc}
+}
+
+func (h *Handler) RegisterRoutes(mux v1.Mux) {
+	// routes repeats the patterns, so the fallback can tell a wrong method from an unknown path
+	routes := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, handler)
+		routes.HandleFunc(pattern, handler)
+	}
+	// Every route only depends on the stored prices, so all support conditional requests
+	handle("GET /api/v2/prices", v1.Cached(h.uc, h.ListPrices))
+	handle("GET /api/v2/prices/{symbol}", v1.Cached(h.uc, h.GetPrice))
+	handle("GET /api/v2/prices/{symbol}/history", v1.Cached(h.uc, h.History))
+	handle("GET /api/v2/prices/{symbol}/candles", v1.Cached(h.uc, h.Candles))
+	handle("GET /api/v2/prices/{symbol}/indicators", v1.Cached(h.uc, h.Indicator))
+	handle("GET /api/v2/convert", v1.Cached(h.uc, h.Convert))
+	mux.HandleFunc("/api/v2/", fallback(routes))
+}
+
+// fallback answers the requests no v2 route matches with problem details: 405 with an
+// Allow header when the path is served for other methods, 404 otherwise.
+func fallback(routes *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if _, pattern := routes.Handler(probe); pattern != "" {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			problem.Write(w, r, http.StatusNotFound, problem.CodeNotFound, "no route for "+r.URL.Path)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		problem.Write(w, r, http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed,
+			r.Method+" is not allowed, use "+strings.Join(allowed, " or "))
+	}
+}
+
+// ListPrices maps to GET /api/v2/prices
+// It takes the filters and sort order of /api/v1/prices, plus limit= and cursor=.
+func (h *Handler) ListPrices(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cal, ok := parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	page, err := h.uc.QueryPrices(r.Context(), usecase.PriceQuery{
+		Types:   queryList(r, "type"),
+		Symbols: queryList(r, "symbol"),
+		Search:  q.Get("q"),
+		Sort:    q.Get("sort"),
+		Limit:   limit,
+		Cursor:  q.Get("cursor"),
+	})
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	prices, ok := h.applyUnit(w, r, page.Prices)
+	if !ok {
+		return
+	}
+	h.writeData(w, v1.ToPriceResponses(prices, cal), newPagination(limit, page.NextCursor))
+}
+
+// GetPrice maps to GET /api/v2/prices/{symbol}
+// It returns the latest price of one symbol.
+func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	cal, ok := parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	quotes, err := h.uc.GetQuotes(r.Context(), []string{symbol})
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if len(quotes) == 0 {
+		writeProblem(w, r, unknownSymbol(symbol))
+		return
+	}
+	quotes, ok = h.applyUnit(w, r, quotes)
+	if !ok {
+		return
+	}
+	h.writeData(w, v1.ToPriceResponses(quotes, cal)[0], nil)
+}
+
+// History maps to GET /api/v2/prices/{symbol}/history
+// It pages the recorded prices within from= and to=, oldest first.
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cal, ok := parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	limit, ok := parseLimit(w, r)
+	if !ok {
+		return
+	}
+	from, to, err := v1.ParseRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeInvalid(w, r, err.Error())
+		return
+	}
+	page, err := h.uc.QueryHistory(r.Context(), usecase.HistoryQuery{
+		Symbol: r.PathValue("symbol"),
+		From:   from,
+		To:     to,
+		Limit:  limit,
+		Cursor: q.Get("cursor"),
+	})
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	prices, ok := h.applyUnit(w, r, page.Prices)
+	if !ok {
+		return
+	}
+	h.writeData(w, v1.ToPriceResponses(prices, cal), newPagination(limit, page.NextCursor))
+}
+
+// Candles maps to GET /api/v2/prices/{symbol}/candles
+// It groups the history into OHLC candles of interval= (1h by default), over the last
+// 30 days unless from= is given.
+func (h *Handler) Candles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cal, ok := parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	interval, err := usecase.ParseInterval(q.Get("interval"))
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	from, to, err := v1.ParseRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		writeInvalid(w, r, err.Error())
+		return
+	}
+	if q.Get("from") == "" {
+		from = to.AddDate(0, 0, -defaultCandleDays)
+	}
+	symbol, err := h.knownSymbol(r)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	candles, err := h.uc.GetCandles(r.Context(), symbol, interval, from, to)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	response := make([]dto.CandleResponse, 0, len(candles))
+	for _, c := range candles {
+		ts := time.Unix(c.TimeUnix, 0)
+		response = append(response, dto.CandleResponse{
+			TimeUnix: c.TimeUnix,
+			Date:     calendar.FormatDate(ts, cal),
+			Time:     calendar.FormatTime(ts),
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Count:    c.Count,
+		})
+	}
+	h.writeData(w, response, nil)
+}
+
+// Indicator maps to GET /api/v2/prices/{symbol}/indicators
+// It takes the parameters of /api/v1/indicators except symbol=, which is in the path.
+func (h *Handler) Indicator(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cal, ok := parseCalendar(w, r)
+	if !ok {
+		return
+	}
+	query := usecase.IndicatorQuery{Type: strings.ToLower(q.Get("type")), Period: 14}
+	if v := q.Get("period"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			writeInvalid(w, r, "invalid period")
+			return
+		}
+		query.Period = p
+	}
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "1h"
+	}
+	var err error
+	if query.Interval, err = usecase.ParseInterval(interval); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	if q.Get("from") != "" || q.Get("to") != "" {
+		from, to, err := v1.ParseRange(q.Get("from"), q.Get("to"))
+		if err != nil {
+			writeInvalid(w, r, err.Error())
+			return
+		}
+		if q.Get("from") != "" {
+			query.From = from
+		}
+		query.To = to
+	}
+	if query.Symbol, err = h.knownSymbol(r); err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	points, err := h.uc.GetIndicator(r.Context(), query)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	h.writeData(w, dto.IndicatorResponse{
+		Symbol:   query.Symbol,
+		Type:     query.Type,
+		Period:   query.Period,
+		Interval: interval,
+		Points:   v1.ToIndicatorPoints(points, cal),
+	}, nil)
+}
+
+// Convert maps to GET /api/v2/convert
+// It takes the parameters of /api/v1/convert.
+func (h *Handler) Convert(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" || to == "" {
+		writeInvalid(w, r, "from and to are required")
+		return
+	}
+	amount := 1.0
+	if v := q.Get("amount"); v != "" {
+		a, err := strconv.ParseFloat(v, 64)
+		if err != nil || a < 0 {
+			writeInvalid(w, r, "invalid amount")
+			return
+		}
+		amount = a
+	}
+	var at time.Time
+	if v := q.Get("at"); v != "" {
+		t, err := calendar.ParseTimestamp(v)
+		if err != nil {
+			writeInvalid(w, r, "invalid at timestamp")
+			return
+		}
+		at = t
+	}
+
+	conv, err := h.uc.Convert(r.Context(), from, to, amount, at)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	h.writeData(w, v1.ToConversionResponse(conv), nil)
+}
+
+// knownSymbol returns the upper-cased {symbol} of the path, or ErrUnknownSymbol when it has
+// no latest price, so unknown symbols are told apart from symbols without history.
+func (h *Handler) knownSymbol(r *http.Request) (string, error) {
+	symbol := r.PathValue("symbol")
+	quotes, err := h.uc.GetQuotes(r.Context(), []string{symbol})
+	if err != nil {
+		return "", err
+	}
+	if len(quotes) == 0 {
+		return "", unknownSymbol(symbol)
+	}
+	return quotes[0].Symbol, nil
+}
+
+// applyUnit converts prices to the unit requested with ?unit=, if any.
+// It writes the error response itself and reports whether the handler may continue.
+func (h *Handler) applyUnit(w http.ResponseWriter, r *http.Request, prices []entity.Price) ([]entity.Price, bool) {
+	raw := r.URL.Query().Get("unit")
+	if raw == "" {
+		return prices, true
+	}
+	unit, ok := entity.ParseUnit(raw)
+	if !ok {
+		writeInvalid(w, r, "invalid unit: "+raw)
+		return nil, false
+	}
+	converted, err := h.uc.ConvertUnits(r.Context(), prices, unit)
+	if err != nil {
+		writeProblem(w, r, err)
+		return nil, false
+	}
+	return converted, true
+}
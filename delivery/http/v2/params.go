@@ -0,0 +1,51 @@
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// parseCalendar reads ?calendar= and writes a problem when it is invalid.
+func parseCalendar(w http.ResponseWriter, r *http.Request) (calendar.Calendar, bool) {
+	cal, ok := calendar.ParseCalendar(r.URL.Query().Get("calendar"))
+	if !ok {
+		writeInvalid(w, r, "calendar must be jalali or gregorian")
+	}
+	return cal, ok
+}
+
+// parseLimit reads ?limit=, zero when it is absent, and writes a problem when it is invalid.
+func parseLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > usecase.MaxPageSize {
+		writeInvalid(w, r, fmt.Sprintf("limit must be between 1 and %d", usecase.MaxPageSize))
+		return 0, false
+	}
+	return n, true
+}
+
+// queryList returns the values of a parameter that may be repeated or comma separated.
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, v := range r.URL.Query()[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+func unknownSymbol(symbol string) error {
+	return fmt.Errorf("%w: %s", usecase.ErrUnknownSymbol, strings.ToUpper(symbol))
+}
@@ -0,0 +1,79 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/delivery/http/problem"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+// Envelope is the body of every successful response.
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
+// Meta describes the data: when the stored prices last changed and, for lists, the page.
+type Meta struct {
+	AsOf       string      `json:"as_of"` // RFC 3339
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination is the position of a list page; pass NextCursor as ?cursor= for the next one.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// writeData writes data in the envelope, with the page when it is a list.
+func (h *Handler) writeData(w http.ResponseWriter, data interface{}, page *Pagination) {
+	_, modified := h.uc.DataVersion()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	env := Envelope{Data: data, Meta: Meta{AsOf: modified.UTC().Format(time.RFC3339), Pagination: page}}
+	if err := json.NewEncoder(w).Encode(env); err != nil {
+		slog.Warn("response encoding failed", "err", err)
+	}
+}
+
+// newPagination describes a page of a list requested with limit, zero for the default.
+func newPagination(limit int, next string) *Pagination {
+	if limit == 0 {
+		limit = usecase.DefaultPageSize
+	}
+	return &Pagination{Limit: limit, NextCursor: next, HasMore: next != ""}
+}
+
+// writeInvalid rejects a request parameter.
+func writeInvalid(w http.ResponseWriter, r *http.Request, detail string) {
+	problem.Write(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, detail)
+}
+
+// writeProblem maps a use case error to problem details. Unexpected errors are logged
+// and reported without their message.
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusInternalServerError, problem.CodeInternal
+	switch {
+	case errors.Is(err, usecase.ErrUnknownSymbol):
+		status, code = http.StatusNotFound, problem.CodeUnknownSymbol
+	case errors.Is(err, usecase.ErrNoConversionPath):
+		status, code = http.StatusNotFound, problem.CodeNoConversionPath
+	case errors.Is(err, entity.ErrNotFound):
+		status, code = http.StatusNotFound, problem.CodeNotFound
+	case errors.Is(err, usecase.ErrInvalidQuery), errors.Is(err, usecase.ErrInvalidIndicator),
+		errors.Is(err, usecase.ErrInvalidInterval):
+		status, code = http.StatusBadRequest, problem.CodeInvalidParameter
+	}
+	detail := err.Error()
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "path", r.URL.Path, "err", err)
+		detail = "the request could not be completed"
+	}
+	problem.Write(w, r, status, code, detail)
+}
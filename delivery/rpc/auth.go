@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/delivery/rpc/pricepb"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func unaryAuth(keys *usecase.APIKeyUseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, keys, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(keys *usecase.APIKeyUseCase) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), keys, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// authorize checks the API key of a call against the scope of its method and counts the
+// call against the key's rate limit, like the HTTP AuthMiddleware.
+func authorize(ctx context.Context, keys *usecase.APIKeyUseCase, method string) error {
+	scope := requiredScope(method)
+	if scope == "" {
+		return nil
+	}
+	key, err := keys.Authenticate(ctx, apiKeyFromMetadata(ctx))
+	if err != nil {
+		return statusFor(ctx, err)
+	}
+	if !key.HasScope(scope) {
+		return statusFor(ctx, fmt.Errorf("%w: %s", usecase.ErrForbidden, scope))
+	}
+	rate, err := keys.Allow(ctx, key)
+	if err != nil {
+		if rate.RetryAfter > 0 {
+			grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter(rate.RetryAfter)))
+		}
+		return statusFor(ctx, err)
+	}
+	return nil
+}
+
+// requiredScope returns the scope a method needs, or "" for the health and reflection services.
+func requiredScope(method string) string {
+	switch {
+	case method == pricepb.PriceService_Subscribe_FullMethodName:
+		return entity.ScopeStream
+	case strings.HasPrefix(method, "/"+pricepb.PriceService_ServiceDesc.ServiceName+"/"):
+		return entity.ScopeRead
+	}
+	return ""
+}
+
+// apiKeyFromMetadata reads the key from "authorization: Bearer <key>" or "x-api-key".
+func apiKeyFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if key, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/delivery/rpc/pricepb"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toQuotes maps price entities to their protobuf messages.
+func toQuotes(prices []entity.Price) []*pricepb.Quote {
+	quotes := make([]*pricepb.Quote, 0, len(prices))
+	for _, p := range prices {
+		price, _ := p.Price.Float64()
+		change, _ := p.ChangeValue.Float64()
+		q := &pricepb.Quote{
+			Symbol:        p.Symbol,
+			Type:          p.Type,
+			NameEn:        p.NameEn,
+			NameFa:        p.NameFa,
+			Price:         price,
+			ChangeValue:   change,
+			ChangePercent: p.ChangePercent,
+			Unit:          entity.NormalizeUnit(p.Unit),
+			TimeUnix:      p.TimeUnix,
+		}
+		// Rows written before time_unix existed only carry the provider's Jalali strings
+		if q.TimeUnix == 0 {
+			if ts, err := calendar.ParseDate(p.Date + " " + p.Time); err == nil {
+				q.TimeUnix = ts.Unix()
+			}
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes
+}
+
+func toCandles(candles []entity.Candle) []*pricepb.Candle {
+	out := make([]*pricepb.Candle, 0, len(candles))
+	for _, c := range candles {
+		out = append(out, &pricepb.Candle{
+			TimeUnix: c.TimeUnix,
+			Open:     c.Open,
+			High:     c.High,
+			Low:      c.Low,
+			Close:    c.Close,
+			Count:    int32(c.Count),
+		})
+	}
+	return out
+}
+
+// parseUnit reads the unit of a request; empty means prices as stored.
+func parseUnit(raw string) (entity.Unit, error) {
+	if raw == "" {
+		return "", nil
+	}
+	unit, ok := entity.ParseUnit(raw)
+	if !ok {
+		return "", status.Error(codes.InvalidArgument, "invalid unit: "+raw)
+	}
+	return unit, nil
+}
+
+func unknownSymbol(symbol string) error {
+	return fmt.Errorf("%w: %s", usecase.ErrUnknownSymbol, strings.ToUpper(symbol))
+}
+
+// statusFor maps use case errors to gRPC status codes. Unexpected errors are logged
+// and reported without their message.
+func statusFor(ctx context.Context, err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, usecase.ErrUnknownSymbol), errors.Is(err, usecase.ErrNoConversionPath),
+		errors.Is(err, entity.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, usecase.ErrInvalidQuery), errors.Is(err, usecase.ErrInvalidInterval),
+		errors.Is(err, usecase.ErrInvalidIndicator):
+		code = codes.InvalidArgument
+	case errors.Is(err, usecase.ErrUnauthorized), errors.Is(err, usecase.ErrInvalidAPIKey):
+		code = codes.Unauthenticated
+	case errors.Is(err, usecase.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, usecase.ErrRateLimited), errors.Is(err, usecase.ErrQuotaExceeded):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	}
+	if code == codes.Internal {
+		slog.ErrorContext(ctx, "grpc call failed", "err", err)
+		return status.Error(code, "the request could not be completed")
+	}
+	return status.Error(code, err.Error())
+}
+
+// retryAfter rounds a rate limit wait up to whole seconds for the retry-after metadata.
+func retryAfter(d time.Duration) string {
+	return fmt.Sprint(int64((d + time.Second - 1) / time.Second))
+}
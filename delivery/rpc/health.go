@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/delivery/rpc/pricepb"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// WatchHealth runs the readiness checks every interval until ctx is done and reports
+// the result through the health service, for the server ("") and PriceService alike.
+func WatchHealth(ctx context.Context, srv *health.Server, checks *usecase.HealthUseCase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if checks.Ready(ctx).Status != usecase.HealthUp {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", status)
+		srv.SetServingStatus(pricepb.PriceService_ServiceDesc.ServiceName, status)
+
+		select {
+		case <-ctx.Done():
+			srv.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
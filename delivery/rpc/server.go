@@ -0,0 +1,247 @@
+// Package rpc serves the price API over gRPC, next to the HTTP server. The
+// PriceService is defined in pricepb/price.proto; the standard health and
+// reflection services are registered with it.
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	"github.com/ar-mokhtari/market-tracker/delivery/rpc/pricepb"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// defaultCandleDays is the range of candles requested without from_unix.
+const defaultCandleDays = 30
+
+const (
+	healthInterval  = 30 * time.Second // How often the health service reruns the readiness checks
+	shutdownTimeout = 10 * time.Second // How long running calls may take to finish on shutdown
+)
+
+// Server implements PriceService on the price use case. Subscribe streams the price
+// broadcasts of the websocket hub, so both streams carry the same updates.
+type Server struct {
+	pricepb.UnimplementedPriceServiceServer
+	uc       *usecase.PriceUseCase
+	hub      *v1.Hub
+	stopping chan struct{} // Closed on shutdown, which ends the Subscribe streams
+}
+
+func NewServer(uc *usecase.PriceUseCase, hub *v1.Hub) *Server {
+	return &Server{uc: uc, hub: hub, stopping: make(chan struct{})}
+}
+
+// API is the gRPC server: PriceService with the health and reflection services.
+type API struct {
+	srv    *Server
+	grpc   *grpc.Server
+	health *health.Server
+	checks *usecase.HealthUseCase
+}
+
+// NewAPI registers s with the health and reflection services. The health service follows
+// checks, the readiness checks of /health/ready. When keys is not nil, PriceService calls
+// need an API key with the read scope, or the stream scope for Subscribe; health checks
+// and reflection stay public.
+func NewAPI(s *Server, keys *usecase.APIKeyUseCase, checks *usecase.HealthUseCase) *API {
+	var opts []grpc.ServerOption
+	if keys != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(unaryAuth(keys)),
+			grpc.ChainStreamInterceptor(streamAuth(keys)))
+	}
+	a := &API{srv: s, grpc: grpc.NewServer(opts...), health: health.NewServer(), checks: checks}
+	pricepb.RegisterPriceServiceServer(a.grpc, s)
+	healthpb.RegisterHealthServer(a.grpc, a.health)
+	reflection.Register(a.grpc)
+	return a
+}
+
+// Serve serves on lis until ctx is done, then stops gracefully: Subscribe streams end,
+// and other calls get shutdownTimeout to finish before they are cancelled. It returns
+// once the server has stopped.
+func (a *API) Serve(ctx context.Context, lis net.Listener) error {
+	go WatchHealth(ctx, a.health, a.checks, healthInterval)
+
+	served := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+		case <-served:
+			return
+		}
+		close(a.srv.stopping)
+		force := time.AfterFunc(shutdownTimeout, a.grpc.Stop)
+		a.grpc.GracefulStop()
+		force.Stop()
+	}()
+
+	err := a.grpc.Serve(lis)
+	close(served)
+	<-stopped
+	return err
+}
+
+// GetQuotes returns the latest prices, of every symbol unless symbols are given.
+func (s *Server) GetQuotes(ctx context.Context, req *pricepb.GetQuotesRequest) (*pricepb.GetQuotesResponse, error) {
+	unit, err := parseUnit(req.GetUnit())
+	if err != nil {
+		return nil, err
+	}
+	var prices []entity.Price
+	if len(req.GetSymbols()) > 0 {
+		prices, err = s.uc.GetQuotes(ctx, req.GetSymbols())
+	} else {
+		prices, err = s.uc.GetPrices(ctx, "")
+	}
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	prices = filterTypes(prices, req.GetTypes())
+	if prices, err = s.convertUnit(ctx, prices, unit); err != nil {
+		return nil, err
+	}
+	_, modified := s.uc.DataVersion()
+	return &pricepb.GetQuotesResponse{Quotes: toQuotes(prices), AsOfUnix: modified.Unix()}, nil
+}
+
+// GetHistory pages the recorded prices of a symbol within from_unix and to_unix.
+func (s *Server) GetHistory(ctx context.Context, req *pricepb.GetHistoryRequest) (*pricepb.GetHistoryResponse, error) {
+	unit, err := parseUnit(req.GetUnit())
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := timeRange(req.GetFromUnix(), req.GetToUnix())
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.uc.QueryHistory(ctx, usecase.HistoryQuery{
+		Symbol: req.GetSymbol(),
+		From:   from,
+		To:     to,
+		Limit:  int(req.GetLimit()),
+		Cursor: req.GetCursor(),
+	})
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	prices, err := s.convertUnit(ctx, page.Prices, unit)
+	if err != nil {
+		return nil, err
+	}
+	return &pricepb.GetHistoryResponse{Prices: toQuotes(prices), NextCursor: page.NextCursor}, nil
+}
+
+// GetCandles groups the history of a symbol into candles of the requested interval.
+func (s *Server) GetCandles(ctx context.Context, req *pricepb.GetCandlesRequest) (*pricepb.GetCandlesResponse, error) {
+	interval, err := usecase.ParseInterval(req.GetInterval())
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	from, to, err := timeRange(req.GetFromUnix(), req.GetToUnix())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetFromUnix() == 0 {
+		from = to.AddDate(0, 0, -defaultCandleDays)
+	}
+	// Unknown symbols are told apart from symbols without history in the range
+	quotes, err := s.uc.GetQuotes(ctx, []string{req.GetSymbol()})
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	if len(quotes) == 0 {
+		return nil, statusFor(ctx, unknownSymbol(req.GetSymbol()))
+	}
+
+	candles, err := s.uc.GetCandles(ctx, quotes[0].Symbol, interval, from, to)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return &pricepb.GetCandlesResponse{Candles: toCandles(candles)}, nil
+}
+
+// Subscribe sends every price broadcast of the hub until the client cancels the call.
+func (s *Server) Subscribe(req *pricepb.SubscribeRequest, stream grpc.ServerStreamingServer[pricepb.PriceUpdate]) error {
+	unit, err := parseUnit(req.GetUnit())
+	if err != nil {
+		return err
+	}
+	updates, unsubscribe := s.hub.Subscribe(req.GetSymbols(), unit)
+	defer unsubscribe()
+
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stopping:
+			return nil
+		case prices, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&pricepb.PriceUpdate{Quotes: toQuotes(prices)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// convertUnit converts prices to unit, if one was requested.
+func (s *Server) convertUnit(ctx context.Context, prices []entity.Price, unit entity.Unit) ([]entity.Price, error) {
+	if unit == "" {
+		return prices, nil
+	}
+	converted, err := s.uc.ConvertUnits(ctx, prices, unit)
+	if err != nil {
+		return nil, statusFor(ctx, err)
+	}
+	return converted, nil
+}
+
+// filterTypes keeps the prices of the given types; no types keeps every price.
+func filterTypes(prices []entity.Price, types []string) []entity.Price {
+	if len(types) == 0 {
+		return prices
+	}
+	out := make([]entity.Price, 0, len(prices))
+	for _, p := range prices {
+		for _, t := range types {
+			if strings.EqualFold(p.Type, t) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// timeRange converts unix seconds to a range; a zero end means now. Like v1.ParseRange,
+// it rejects a start after the end, and negative times as well.
+func timeRange(fromUnix, toUnix int64) (time.Time, time.Time, error) {
+	if fromUnix < 0 || toUnix < 0 {
+		return time.Time{}, time.Time{}, status.Error(codes.InvalidArgument, "from_unix and to_unix must not be negative")
+	}
+	from, to := time.Unix(fromUnix, 0), time.Now()
+	if toUnix != 0 {
+		to = time.Unix(toUnix, 0)
+	}
+	if to.Before(from) {
+		return from, to, status.Error(codes.InvalidArgument, "from_unix must be before to_unix")
+	}
+	return from, to, nil
+}
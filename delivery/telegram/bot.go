@@ -0,0 +1,302 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+const pollTimeoutSeconds = 30
+
+// summarySymbols are the quotes posted in scheduled channel summaries.
+var summarySymbols = []string{"USD", "EUR", "AED", "IR_GOLD_18K", "IR_COIN_EMAMI", "XAUUSD", "BTC", "ETH"}
+
+const helpText = `Market Tracker bot
+
+/price USD [EUR ...] - latest prices
+/gold - gold and coin prices
+/currency - currency prices
+/crypto - cryptocurrency prices
+/convert 100 USD [EUR] - convert an amount (default to IRT)
+/alert USD above 100000 - get notified when a price crosses a value
+/subscribe [USD BTC ...] - receive alerts, optionally only for some symbols
+/unsubscribe - stop receiving alerts`
+
+type Bot struct {
+	client       *Client
+	prices       *usecase.PriceUseCase
+	alerts       *usecase.AlertUseCase
+	subs         usecase.SubscriptionRepo
+	channels     []string
+	summaryEvery time.Duration
+}
+
+func NewBot(client *Client, prices *usecase.PriceUseCase, alerts *usecase.AlertUseCase, subs usecase.SubscriptionRepo,
+	channels []string, summaryEvery time.Duration) *Bot {
+	return &Bot{
+		client:       client,
+		prices:       prices,
+		alerts:       alerts,
+		subs:         subs,
+		channels:     channels,
+		summaryEvery: summaryEvery,
+	}
+}
+
+// Run answers commands until ctx is cancelled, and posts channel summaries when configured.
+func (b *Bot) Run(ctx context.Context) {
+	if b.summaryEvery > 0 && len(b.channels) > 0 {
+		go b.runSummaries(ctx)
+	}
+
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := b.client.GetUpdates(ctx, offset, pollTimeoutSeconds)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "telegram polling failed", "err", err)
+				time.Sleep(5 * time.Second)
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+				continue
+			}
+			reply := b.Handle(ctx, u.Message.Chat.ID, u.Message.Text)
+			if err := b.client.SendMessage(ctx, u.Message.Chat.ID, reply); err != nil {
+				slog.ErrorContext(ctx, "telegram reply failed", "chat_id", u.Message.Chat.ID, "err", err)
+			}
+		}
+	}
+}
+
+// Handle executes one command and returns the reply text.
+func (b *Bot) Handle(ctx context.Context, chatID int64, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return helpText
+	}
+	// Commands in groups arrive as /price@BotName
+	cmd, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+	args := fields[1:]
+
+	switch cmd {
+	case "/start", "/help":
+		return helpText
+	case "/price":
+		return b.price(ctx, args)
+	case "/gold":
+		return b.category(ctx, "gold")
+	case "/currency":
+		return b.category(ctx, "currency")
+	case "/crypto":
+		return b.category(ctx, "cryptocurrency")
+	case "/convert":
+		return b.convert(ctx, args)
+	case "/alert":
+		return b.alert(ctx, chatID, args)
+	case "/subscribe":
+		return b.subscribe(ctx, chatID, args)
+	case "/unsubscribe":
+		return b.unsubscribe(ctx, chatID)
+	}
+	return "Unknown command.\n\n" + helpText
+}
+
+func (b *Bot) price(ctx context.Context, args []string) string {
+	if len(args) == 0 {
+		return "Usage: /price USD [EUR ...]"
+	}
+	quotes, err := b.prices.GetQuotes(ctx, args)
+	if err != nil {
+		slog.ErrorContext(ctx, "telegram price failed", "err", err)
+		return "Prices are unavailable right now."
+	}
+	if len(quotes) == 0 {
+		return "No price found for " + strings.ToUpper(strings.Join(args, ", "))
+	}
+	return formatQuotes(quotes)
+}
+
+func (b *Bot) category(ctx context.Context, priceType string) string {
+	quotes, err := b.prices.GetPrices(ctx, priceType)
+	if err != nil {
+		slog.ErrorContext(ctx, "telegram category failed", "type", priceType, "err", err)
+		return "Prices are unavailable right now."
+	}
+	if len(quotes) == 0 {
+		return "No prices yet."
+	}
+	return formatQuotes(quotes)
+}
+
+func (b *Bot) convert(ctx context.Context, args []string) string {
+	if len(args) < 2 {
+		return "Usage: /convert 100 USD [EUR]"
+	}
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[0], ",", ""), 64)
+	if err != nil || amount < 0 {
+		return "Invalid amount: " + args[0]
+	}
+	to := string(entity.UnitIRT)
+	if len(args) > 2 {
+		to = args[2]
+	}
+
+	conv, err := b.prices.Convert(ctx, args[1], to, amount, time.Time{})
+	if err != nil {
+		if errors.Is(err, usecase.ErrUnknownSymbol) || errors.Is(err, usecase.ErrNoConversionPath) {
+			return err.Error()
+		}
+		slog.ErrorContext(ctx, "telegram convert failed", "err", err)
+		return "Conversion is unavailable right now."
+	}
+	return fmt.Sprintf("%s %s = %s %s\nvia %s", formatNumber(conv.Amount), conv.From, formatNumber(conv.Result), conv.To,
+		strings.Join(conv.Path, " → "))
+}
+
+func (b *Bot) alert(ctx context.Context, chatID int64, args []string) string {
+	if len(args) != 3 {
+		return "Usage: /alert USD above 100000"
+	}
+	threshold, err := strconv.ParseFloat(strings.ReplaceAll(args[2], ",", ""), 64)
+	if err != nil {
+		return "Invalid value: " + args[2]
+	}
+
+	alert := entity.Alert{ChatID: chatID, Symbol: args[0], Kind: entity.AlertKindPrice, Condition: args[1], Threshold: threshold}
+	if err := b.alerts.Create(ctx, &alert); err != nil {
+		if errors.Is(err, usecase.ErrInvalidAlert) {
+			return err.Error()
+		}
+		slog.ErrorContext(ctx, "telegram alert failed", "chat_id", chatID, "err", err)
+		return "Could not create the alert."
+	}
+	return fmt.Sprintf("Alert #%d created: %s %s %s", alert.ID, alert.Symbol, alert.Condition, formatNumber(alert.Threshold))
+}
+
+func (b *Bot) subscribe(ctx context.Context, chatID int64, args []string) string {
+	symbols := make([]string, 0, len(args))
+	for _, a := range args {
+		symbols = append(symbols, strings.ToUpper(a))
+	}
+	if err := b.subs.SaveChatSubscription(ctx, entity.ChatSubscription{ChatID: chatID, Symbols: symbols}); err != nil {
+		slog.ErrorContext(ctx, "telegram subscription failed", "chat_id", chatID, "err", err)
+		return "Could not subscribe this chat."
+	}
+	if len(symbols) == 0 {
+		return "Subscribed to all alerts."
+	}
+	return "Subscribed to alerts for " + strings.Join(symbols, ", ")
+}
+
+func (b *Bot) unsubscribe(ctx context.Context, chatID int64) string {
+	err := b.subs.DeleteChatSubscription(ctx, chatID)
+	if errors.Is(err, entity.ErrNotFound) {
+		return "This chat was not subscribed."
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "telegram unsubscribe failed", "chat_id", chatID, "err", err)
+		return "Could not unsubscribe this chat."
+	}
+	return "Unsubscribed."
+}
+
+// Notify sends a triggered alert to the chat that created it, or else to every subscribed chat,
+// so the bot plugs into the alert engine as a Notifier.
+func (b *Bot) Notify(ctx context.Context, event entity.AlertEvent) error {
+	if event.ChatID != 0 {
+		return b.client.SendMessage(ctx, event.ChatID, "🔔 "+event.Message)
+	}
+	if event.UserID != 0 {
+		return nil // Personal alerts are only delivered to their owner
+	}
+	subs, err := b.subs.ListChatSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	text := "🔔 " + event.Message
+	for _, s := range subs {
+		if len(s.Symbols) > 0 && !slices.Contains(s.Symbols, event.Symbol) {
+			continue
+		}
+		if err := b.client.SendMessage(ctx, s.ChatID, text); err != nil {
+			slog.WarnContext(ctx, "telegram alert delivery failed", "chat_id", s.ChatID, "err", err)
+		}
+	}
+	return nil
+}
+
+func (b *Bot) runSummaries(ctx context.Context) {
+	ticker := time.NewTicker(b.summaryEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.PostSummary(ctx)
+		}
+	}
+}
+
+// PostSummary sends the market summary to every configured channel.
+func (b *Bot) PostSummary(ctx context.Context) {
+	quotes, err := b.prices.GetQuotes(ctx, summarySymbols)
+	if err != nil || len(quotes) == 0 {
+		slog.WarnContext(ctx, "telegram summary skipped", "err", err)
+		return
+	}
+	text := "📊 Market summary\n\n" + formatQuotes(quotes)
+	for _, ch := range b.channels {
+		if err := b.client.SendMessage(ctx, ch, text); err != nil {
+			slog.WarnContext(ctx, "telegram summary delivery failed", "channel", ch, "err", err)
+		}
+	}
+}
+
+func formatQuotes(quotes []entity.Price) string {
+	lines := make([]string, 0, len(quotes))
+	for _, p := range quotes {
+		v, _ := p.Price.Float64()
+		name := p.Symbol
+		if p.NameEn != "" {
+			name = fmt.Sprintf("%s (%s)", p.NameEn, p.Symbol)
+		}
+		line := fmt.Sprintf("%s: %s %s", name, formatNumber(v), entity.NormalizeUnit(p.Unit))
+		if p.ChangePercent != 0 {
+			line += fmt.Sprintf(" (%+.2f%%)", p.ChangePercent)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatNumber groups thousands and keeps decimals only for small values.
+func formatNumber(v float64) string {
+	if math.Abs(v) < 100 {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(math.Round(v), 'f', 0, 64)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
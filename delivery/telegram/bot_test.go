@@ -0,0 +1,260 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/usecase"
+)
+
+const testToken = "123:test-token"
+
+// sentMessage is a sendMessage call received by the fake Bot API.
+type sentMessage struct {
+	ChatID json.Number `json:"chat_id"`
+	Text   string      `json:"text"`
+}
+
+// fakeBotAPI serves getUpdates and sendMessage like api.telegram.org. Queued updates are
+// returned by the next getUpdates call; sent messages arrive on sent, and idle is signalled
+// when the bot polls with nothing left to deliver.
+type fakeBotAPI struct {
+	t    *testing.T
+	mu   sync.Mutex
+	next []Update
+	sent chan sentMessage
+	idle chan struct{}
+}
+
+func newFakeBotAPI(t *testing.T, updates ...Update) (*fakeBotAPI, *Client) {
+	f := &fakeBotAPI{t: t, next: updates, sent: make(chan sentMessage, 16), idle: make(chan struct{}, 1)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, NewClient(srv.URL, testToken)
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+testToken+"/")
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(apiResponse{Description: "Unauthorized"})
+		return
+	}
+
+	var result interface{} = true
+	switch method {
+	case "getUpdates":
+		f.mu.Lock()
+		updates := f.next
+		f.next = nil
+		f.mu.Unlock()
+		if len(updates) == 0 {
+			select {
+			case f.idle <- struct{}{}:
+			default:
+			}
+			// Stands in for the long poll without keeping the test waiting
+			time.Sleep(10 * time.Millisecond)
+			updates = []Update{}
+		}
+		result = updates
+	case "sendMessage":
+		var m sentMessage
+		d := json.NewDecoder(r.Body)
+		d.UseNumber()
+		if err := d.Decode(&m); err != nil {
+			f.t.Errorf("sendMessage body: %v", err)
+		}
+		f.sent <- m
+	default:
+		f.t.Errorf("unexpected method %s", method)
+	}
+	raw, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(apiResponse{OK: true, Result: raw})
+}
+
+// receive waits for the next sent message.
+func (f *fakeBotAPI) receive(t *testing.T) sentMessage {
+	t.Helper()
+	select {
+	case m := <-f.sent:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message sent")
+		return sentMessage{}
+	}
+}
+
+// expectNone fails when a message is sent within a short wait.
+func (f *fakeBotAPI) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case m := <-f.sent:
+		t.Fatalf("unexpected message to %s: %q", m.ChatID, m.Text)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// priceRepo serves the latest prices; the other methods are not used by the bot.
+type priceRepo struct {
+	usecase.Repo
+}
+
+func (priceRepo) ListLatest(context.Context) ([]entity.Price, error) {
+	return []entity.Price{
+		{Symbol: "USD", NameEn: "US Dollar", Type: "currency", Price: "105000", Unit: "IRT"},
+		{Symbol: "BTC", NameEn: "Bitcoin", Type: "cryptocurrency", Price: "60000", Unit: "USD"},
+	}, nil
+}
+
+// alertRepo stores created alerts in memory.
+type alertRepo struct {
+	usecase.AlertRepo
+	created []entity.Alert
+}
+
+func (r *alertRepo) CreateAlert(_ context.Context, a *entity.Alert) error {
+	a.ID = uint(len(r.created) + 1)
+	r.created = append(r.created, *a)
+	return nil
+}
+
+// subscriptionRepo keeps chat subscriptions in memory.
+type subscriptionRepo struct {
+	mu   sync.Mutex
+	subs map[int64]entity.ChatSubscription
+}
+
+func (r *subscriptionRepo) SaveChatSubscription(_ context.Context, s entity.ChatSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[s.ChatID] = s
+	return nil
+}
+
+func (r *subscriptionRepo) GetChatSubscription(_ context.Context, chatID int64) (entity.ChatSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.subs[chatID]
+	if !ok {
+		return s, entity.ErrNotFound
+	}
+	return s, nil
+}
+
+func (r *subscriptionRepo) DeleteChatSubscription(_ context.Context, chatID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[chatID]; !ok {
+		return entity.ErrNotFound
+	}
+	delete(r.subs, chatID)
+	return nil
+}
+
+func (r *subscriptionRepo) ListChatSubscriptions(context.Context) ([]entity.ChatSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []entity.ChatSubscription
+	for _, s := range r.subs {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func newTestBot(client *Client) (*Bot, *alertRepo, *subscriptionRepo) {
+	alerts := &alertRepo{}
+	subs := &subscriptionRepo{subs: make(map[int64]entity.ChatSubscription)}
+	prices := usecase.NewPriceUseCase(priceRepo{}, "", "", time.Minute)
+	return NewBot(client, prices, usecase.NewAlertUseCase(alerts, priceRepo{}), subs, nil, 0), alerts, subs
+}
+
+func message(updateID, chatID int64, text string) Update {
+	return Update{UpdateID: updateID, Message: &Message{MessageID: updateID, Chat: Chat{ID: chatID}, Text: text}}
+}
+
+func TestBotAnswersCommands(t *testing.T) {
+	api, client := newFakeBotAPI(t,
+		message(1, 42, "/price usd"),
+		message(2, 42, "hello"), // Not a command, ignored
+		message(3, 42, "/alert USD above 110000"),
+		message(4, 7, "/subscribe btc"),
+		message(5, 7, "/nope"),
+	)
+	bot, alerts, subs := newTestBot(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		bot.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		// Stop once every update is answered so no reply is cut off
+		<-api.idle
+		cancel()
+		<-done
+	}()
+
+	want := []struct {
+		chatID string
+		text   string
+	}{
+		{"42", "US Dollar (USD): 105,000 IRT"},
+		{"42", "Alert #1 created: USD above 110,000"},
+		{"7", "Subscribed to alerts for BTC"},
+		{"7", "Unknown command."},
+	}
+	for _, w := range want {
+		m := api.receive(t)
+		if m.ChatID.String() != w.chatID || !strings.HasPrefix(m.Text, w.text) {
+			t.Errorf("sent %q to %s, want %q to %s", m.Text, m.ChatID, w.text, w.chatID)
+		}
+	}
+
+	if len(alerts.created) != 1 || alerts.created[0].ChatID != 42 {
+		t.Errorf("alerts = %+v, want one alert of chat 42", alerts.created)
+	}
+	if s, err := subs.GetChatSubscription(ctx, 7); err != nil || len(s.Symbols) != 1 || s.Symbols[0] != "BTC" {
+		t.Errorf("subscription of chat 7 = %+v, %v", s, err)
+	}
+}
+
+func TestBotPushesAlerts(t *testing.T) {
+	api, client := newFakeBotAPI(t)
+	bot, _, subs := newTestBot(client)
+	ctx := context.Background()
+	subs.SaveChatSubscription(ctx, entity.ChatSubscription{ChatID: 7, Symbols: []string{"BTC"}})
+	subs.SaveChatSubscription(ctx, entity.ChatSubscription{ChatID: 8, Symbols: []string{"ETH"}})
+
+	// An alert created in a chat only goes back to that chat
+	if err := bot.Notify(ctx, entity.AlertEvent{ChatID: 42, Symbol: "USD", Message: "USD above 110,000"}); err != nil {
+		t.Fatal(err)
+	}
+	if m := api.receive(t); m.ChatID.String() != "42" || m.Text != "🔔 USD above 110,000" {
+		t.Errorf("sent %q to %s", m.Text, m.ChatID)
+	}
+	api.expectNone(t)
+
+	// Alerts without owner go to the chats subscribed to their symbol
+	if err := bot.Notify(ctx, entity.AlertEvent{Symbol: "BTC", Message: "BTC moved 5%"}); err != nil {
+		t.Fatal(err)
+	}
+	if m := api.receive(t); m.ChatID.String() != "7" || m.Text != "🔔 BTC moved 5%" {
+		t.Errorf("sent %q to %s", m.Text, m.ChatID)
+	}
+	api.expectNone(t)
+
+	// Personal alerts of a user are not pushed to chats
+	if err := bot.Notify(ctx, entity.AlertEvent{UserID: 3, Symbol: "BTC", Message: "BTC moved 5%"}); err != nil {
+		t.Fatal(err)
+	}
+	api.expectNone(t)
+}
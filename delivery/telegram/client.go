@@ -0,0 +1,127 @@
+// Package telegram serves price queries and alerts through a Telegram bot.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a minimal Telegram Bot API client. The base URL is configurable
+// so a local fake server can stand in for api.telegram.org.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second, // Longer than the long-polling timeout
+		},
+	}
+}
+
+// GetUpdates long-polls for messages after offset.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeoutSeconds int) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeoutSeconds,
+		"allowed_updates": []string{"message"},
+	}, &updates)
+	return updates, err
+}
+
+// GetMe returns the bot account, which confirms the token is valid.
+func (c *Client) GetMe(ctx context.Context) (User, error) {
+	var me User
+	err := c.call(ctx, "getMe", map[string]interface{}{}, &me)
+	return me, err
+}
+
+// SendMessage posts text to a chat. chatID may be numeric or a channel username like @market.
+func (c *Client) SendMessage(ctx context.Context, chatID interface{}, text string) error {
+	return c.call(ctx, "sendMessage", map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", redactToken(err, c.baseURL, method))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("network level error: %w", redactToken(err, c.baseURL, method))
+	}
+	defer resp.Body.Close()
+
+	var out apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !out.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, out.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(out.Result, result)
+	}
+	return nil
+}
+
+// redactToken removes the bot token, which is part of the URL path, from a URL error so it
+// stays out of logs.
+func redactToken(err error, baseURL, method string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = fmt.Sprintf("%s/bot<redacted>/%s", baseURL, method)
+	}
+	return err
+}
@@ -24,3 +24,12 @@ CREATE TABLE IF NOT EXISTS prices (
     INDEX idx_created_at (created_at),
     UNIQUE KEY unique_symbol_type (symbol, type)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS price_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    symbol VARCHAR(50) NOT NULL,
+    price VARCHAR(50),
+    type VARCHAR(20) NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_symbol_recorded_at (symbol, recorded_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
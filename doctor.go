@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/migration"
+	"github.com/ar-mokhtari/market-tracker/adapter/storage/redis"
+	config "github.com/ar-mokhtari/market-tracker/config"
+	delivery "github.com/ar-mokhtari/market-tracker/delivery/http"
+	"github.com/ar-mokhtari/market-tracker/delivery/telegram"
+)
+
+const doctorTimeout = 15 * time.Second
+
+// doctorCheck inspects one dependency and describes what it found.
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// doctor reports the state of every dependency the server needs. Unlike the other
+// commands it never exits on the first problem. It loads the configuration itself, as
+// the other checks cannot run without a valid one.
+func doctor(args []string) error {
+	if len(args) > 0 {
+		return usageError("doctor takes no arguments")
+	}
+
+	source := "environment"
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		source = path + " and environment"
+	}
+	cfg, err := config.LoadEnv()
+	if err != nil {
+		var ve *config.ValidationError
+		if errors.As(err, &ve) {
+			err = errors.New(strings.Join(ve.Problems, "; "))
+		}
+		fmt.Printf("FAIL  %-11s %v\n", "config", err)
+		return errors.New("the configuration is invalid, the other checks need a valid one")
+	}
+
+	database, err := sql.Open("mysql", cfg.DSN())
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	checks := []doctorCheck{
+		{"config", func(context.Context) (string, error) { return "valid, from " + source, nil }},
+		{"database", func(ctx context.Context) (string, error) {
+			var version string
+			if err := database.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+				return "", err
+			}
+			return "MySQL " + version, nil
+		}},
+		{"migrations", func(context.Context) (string, error) {
+			current, err := migration.Version(database)
+			if err != nil {
+				return "", err
+			}
+			if latest := migration.Latest(); current < latest {
+				return "", fmt.Errorf("schema version %d, %d pending, run migrate", current, latest-current)
+			}
+			return fmt.Sprintf("schema version %d", current), nil
+		}},
+		{"provider", func(ctx context.Context) (string, error) {
+			n, err := delivery.NewPriceUseCase(database, cfg).CheckProvider(ctx)
+			if err != nil {
+				return "", err
+			}
+			if n == 0 {
+				return "", errors.New("the provider returned no prices")
+			}
+			return fmt.Sprintf("%d symbols from %s", n, cfg.Provider.BaseURL), nil
+		}},
+	}
+	if cfg.Cache.Host != "" {
+		checks = append(checks, doctorCheck{"cache", func(ctx context.Context) (string, error) {
+			store := redis.NewQuoteStore(cfg.Cache.Host, cfg.Cache.Password, cfg.Cache.DB)
+			defer store.Close()
+			version, err := store.Ping(ctx)
+			if err != nil {
+				return "", err
+			}
+			return "Redis " + version + " at " + cfg.Cache.Host, nil
+		}})
+	}
+	if cfg.Telegram.Token != "" {
+		checks = append(checks, doctorCheck{"telegram", func(ctx context.Context) (string, error) {
+			me, err := telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.Token).GetMe(ctx)
+			if err != nil {
+				return "", err
+			}
+			return "bot @" + me.Username, nil
+		}})
+	}
+
+	failed := 0
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		detail, err := c.run(ctx)
+		cancel()
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL  %-11s %v\n", c.name, err)
+			continue
+		}
+		fmt.Printf("ok    %-11s %s\n", c.name, detail)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package dto
+
+// AlertRequest is the body accepted when registering an alert
+type AlertRequest struct {
+	Symbol          string  `json:"symbol"`
+	Kind            string  `json:"kind"`      // price, change or bubble
+	Condition       string  `json:"condition"` // above/below, or up/down/any for change alerts
+	Threshold       float64 `json:"threshold"`
+	Unit            string  `json:"unit,omitempty"`
+	WindowSeconds   int64   `json:"window_seconds,omitempty"`
+	Mode            string  `json:"mode,omitempty"` // once or recurring
+	CooldownSeconds int64   `json:"cooldown_seconds,omitempty"`
+}
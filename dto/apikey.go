@@ -0,0 +1,9 @@
+package dto
+
+// APIKeyRequest is the body accepted when creating an API key
+type APIKeyRequest struct {
+	Name       string   `json:"name"`
+	Scopes     []string `json:"scopes"`      // read, stream, admin; defaults to read
+	RateLimit  int      `json:"rate_limit"`  // Requests per minute, 0 means unlimited
+	DailyQuota int64    `json:"daily_quota"` // Requests per day, 0 means unlimited
+}
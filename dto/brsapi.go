@@ -2,10 +2,19 @@ package dto
 
 // PriceResponse defines how price data looks like for the client
 type PriceResponse struct {
-	Date   string  `json:"date"`
-	Time   string  `json:"time"`
-	Symbol string  `json:"symbol"`
-	Price  float64 `json:"price"`
-	Type   string  `json:"type"`
-	Unit   string  `json:"unit"`
+	Date          string  `json:"date"` // In the calendar chosen with ?calendar=
+	Time          string  `json:"time"`
+	DateJalali    string  `json:"date_jalali"`
+	DateGregorian string  `json:"date_gregorian"`
+	TimeUnix      int64   `json:"time_unix"`
+	Symbol        string  `json:"symbol"`
+	NameEn        string  `json:"name_en,omitempty"`
+	NameFa        string  `json:"name_fa,omitempty"`
+	Price         float64 `json:"price"`
+	ChangePercent float64 `json:"change_percent,omitempty"`
+	Type          string  `json:"type"`
+	Unit          string  `json:"unit"`
+	UnitEn        string  `json:"unit_en"`
+	UnitFa        string  `json:"unit_fa"`
+	UpdatedAt     int64   `json:"updated_at,omitempty"` // Unix seconds of the last change, latest prices only
 }
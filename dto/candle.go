@@ -0,0 +1,13 @@
+package dto
+
+// CandleResponse is one OHLC candle of a symbol's recorded prices
+type CandleResponse struct {
+	TimeUnix int64   `json:"time_unix"` // Start of the interval
+	Date     string  `json:"date"`
+	Time     string  `json:"time"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Count    int     `json:"count"` // Recorded changes inside the interval, 0 for carried candles
+}
@@ -0,0 +1,21 @@
+package dto
+
+// ConversionStepResponse describes one rate used while converting
+type ConversionStepResponse struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Symbol string  `json:"symbol"`
+	Rate   float64 `json:"rate"`
+}
+
+// ConversionResponse defines the result of a currency conversion for the client
+type ConversionResponse struct {
+	From   string                   `json:"from"`
+	To     string                   `json:"to"`
+	Amount float64                  `json:"amount"`
+	Result float64                  `json:"result"`
+	Rate   float64                  `json:"rate"`
+	Path   []string                 `json:"path"`
+	Steps  []ConversionStepResponse `json:"steps"`
+	At     *int64                   `json:"at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// HealthResponse is returned by /health/live and /health/ready
+type HealthResponse struct {
+	Status        string                       `json:"status"` // up or down
+	UptimeSeconds int64                        `json:"uptime_seconds"`
+	CheckedAt     int64                        `json:"checked_at"`
+	Components    map[string]ComponentResponse `json:"components,omitempty"`
+}
+
+// ComponentResponse is the state of one dependency checked for readiness
+type ComponentResponse struct {
+	Status string                 `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Detail map[string]interface{} `json:"detail,omitempty"`
+}
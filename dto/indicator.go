@@ -0,0 +1,20 @@
+package dto
+
+// IndicatorPointResponse is one computed indicator value
+type IndicatorPointResponse struct {
+	TimeUnix int64    `json:"time_unix"`
+	Date     string   `json:"date"`
+	Time     string   `json:"time"`
+	Value    float64  `json:"value"`
+	Upper    *float64 `json:"upper,omitempty"`
+	Lower    *float64 `json:"lower,omitempty"`
+}
+
+// IndicatorResponse defines how an indicator series looks like for the client
+type IndicatorResponse struct {
+	Symbol   string                   `json:"symbol"`
+	Type     string                   `json:"type"`
+	Period   int                      `json:"period"`
+	Interval string                   `json:"interval"`
+	Points   []IndicatorPointResponse `json:"points"`
+}
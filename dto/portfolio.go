@@ -0,0 +1,69 @@
+package dto
+
+// PortfolioRequest is the body accepted when creating a portfolio
+type PortfolioRequest struct {
+	Name string `json:"name"`
+}
+
+// TransactionRequest is the body accepted when recording a buy or sell
+type TransactionRequest struct {
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"` // buy or sell
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"`       // Per unit of quantity
+	Unit       string  `json:"unit"`        // Currency of price, defaults to IRT
+	ExecutedAt string  `json:"executed_at"` // Unix seconds, RFC 3339, or a Jalali or Gregorian date; defaults to now
+	Note       string  `json:"note"`
+}
+
+// HoldingResponse is one position valued at the latest prices
+type HoldingResponse struct {
+	Symbol     string  `json:"symbol"`
+	Type       string  `json:"type"`
+	Quantity   float64 `json:"quantity"`
+	AvgCostIRT float64 `json:"avg_cost_irt"`
+	CostIRT    float64 `json:"cost_irt"`
+	CostUSD    float64 `json:"cost_usd"`
+	PriceIRT   float64 `json:"price_irt"`
+	ValueIRT   float64 `json:"value_irt"`
+	ValueUSD   float64 `json:"value_usd"`
+	PnLIRT     float64 `json:"pnl_irt"`
+	PnLUSD     float64 `json:"pnl_usd"`
+	PnLPercent float64 `json:"pnl_percent"`
+	Priced     bool    `json:"priced"` // False when no price is available to value the holding
+}
+
+// AllocationResponse is the share of the portfolio held in one price type
+type AllocationResponse struct {
+	Type     string  `json:"type"`
+	ValueIRT float64 `json:"value_irt"`
+	Percent  float64 `json:"percent"`
+}
+
+// PortfolioValuationResponse values a portfolio in toman and USD
+type PortfolioValuationResponse struct {
+	ID            uint                 `json:"id"`
+	Name          string               `json:"name"`
+	ValueIRT      float64              `json:"value_irt"`
+	ValueUSD      float64              `json:"value_usd"`
+	CostIRT       float64              `json:"cost_irt"`
+	CostUSD       float64              `json:"cost_usd"`
+	UnrealizedIRT float64              `json:"unrealized_pnl_irt"`
+	UnrealizedUSD float64              `json:"unrealized_pnl_usd"`
+	RealizedIRT   float64              `json:"realized_pnl_irt"`
+	RealizedUSD   float64              `json:"realized_pnl_usd"`
+	PnLPercent    float64              `json:"pnl_percent"`
+	Holdings      []HoldingResponse    `json:"holdings"`
+	Allocation    []AllocationResponse `json:"allocation"`
+	AsOf          int64                `json:"as_of"`
+}
+
+// PortfolioPointResponse is the value of a portfolio at one time
+type PortfolioPointResponse struct {
+	TimeUnix int64   `json:"time_unix"`
+	Date     string  `json:"date"`
+	Time     string  `json:"time"`
+	ValueIRT float64 `json:"value_irt"`
+	ValueUSD float64 `json:"value_usd"`
+	CostIRT  float64 `json:"cost_irt"`
+}
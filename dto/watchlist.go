@@ -0,0 +1,24 @@
+package dto
+
+// WatchlistRequest is the body accepted when creating or replacing a watchlist
+type WatchlistRequest struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+// QuoteResponse is a latest price together with its names and change since the previous close
+type QuoteResponse struct {
+	PriceResponse
+	NameEn        string  `json:"name_en"`
+	NameFa        string  `json:"name_fa"`
+	ChangeValue   float64 `json:"change_value"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+// WatchlistQuotesResponse lists the quotes of a watchlist and the symbols that have no price yet
+type WatchlistQuotesResponse struct {
+	ID      uint            `json:"id"`
+	Name    string          `json:"name"`
+	Quotes  []QuoteResponse `json:"quotes"`
+	Missing []string        `json:"missing"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+// WebhookRequest is the body accepted when registering a webhook
+type WebhookRequest struct {
+	URL      string   `json:"url"`
+	Secret   string   `json:"secret,omitempty"` // Generated when empty
+	Events   []string `json:"events"`           // price.changed, alert.triggered; empty means all
+	Symbols  []string `json:"symbols"`
+	Types    []string `json:"types"`
+	AlertIDs []uint   `json:"alert_ids"`
+}
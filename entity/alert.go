@@ -0,0 +1,141 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert kinds.
+const (
+	AlertKindPrice  = "price"  // Price crosses a threshold
+	AlertKindChange = "change" // Price moves by a percentage within a window
+	AlertKindBubble = "bubble" // Gold coin premium over its gold content exceeds a percentage
+)
+
+// Alert conditions. Price and bubble alerts use above/below, change alerts use up/down/any.
+const (
+	AlertAbove = "above"
+	AlertBelow = "below"
+	AlertUp    = "up"
+	AlertDown  = "down"
+	AlertAny   = "any"
+)
+
+// Alert delivery modes.
+const (
+	AlertOnce      = "once"      // Deactivated after the first trigger
+	AlertRecurring = "recurring" // Fires again once the cooldown has passed
+)
+
+// Defaults filled in by Normalize.
+const (
+	defaultChangeWindow  = time.Hour
+	defaultAlertCooldown = time.Hour
+)
+
+// Alert is a user-defined rule evaluated after every fetch cycle.
+type Alert struct {
+	ID              uint       `json:"id"`
+	UserID          uint       `json:"user_id,omitempty"` // Owner, 0 for alerts created without a user
+	ChatID          int64      `json:"chat_id,omitempty"` // Telegram chat that created the alert, which alone receives it
+	Symbol          string     `json:"symbol"`
+	Kind            string     `json:"kind"`
+	Condition       string     `json:"condition"`
+	Threshold       float64    `json:"threshold"`
+	Unit            string     `json:"unit,omitempty"`           // Price alerts only, defaults to the symbol's unit
+	WindowSeconds   int64      `json:"window_seconds,omitempty"` // Change alerts only
+	Mode            string     `json:"mode"`
+	CooldownSeconds int64      `json:"cooldown_seconds"`
+	Active          bool       `json:"active"`
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Normalize checks an alert and fills in the defaults of its kind and mode. Alerts created
+// through the API and the rules of the configuration file follow these same rules.
+func (a *Alert) Normalize() error {
+	a.Symbol = strings.ToUpper(strings.TrimSpace(a.Symbol))
+	a.Kind = strings.ToLower(a.Kind)
+	a.Condition = strings.ToLower(a.Condition)
+	a.Mode = strings.ToLower(a.Mode)
+
+	if a.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidAlert)
+	}
+	if a.Kind == "" {
+		a.Kind = AlertKindPrice
+	}
+
+	switch a.Kind {
+	case AlertKindPrice:
+		if a.Condition != AlertAbove && a.Condition != AlertBelow {
+			return fmt.Errorf("%w: price alerts need condition above or below", ErrInvalidAlert)
+		}
+		if a.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be positive", ErrInvalidAlert)
+		}
+		if a.Unit != "" {
+			u, ok := ParseUnit(a.Unit)
+			if !ok {
+				return fmt.Errorf("%w: unknown unit %q", ErrInvalidAlert, a.Unit)
+			}
+			a.Unit = string(u)
+		}
+	case AlertKindChange:
+		if a.Condition == "" {
+			a.Condition = AlertAny
+		}
+		if a.Condition != AlertUp && a.Condition != AlertDown && a.Condition != AlertAny {
+			return fmt.Errorf("%w: change alerts need condition up, down or any", ErrInvalidAlert)
+		}
+		if a.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be a positive percentage", ErrInvalidAlert)
+		}
+		if a.WindowSeconds <= 0 {
+			a.WindowSeconds = int64(defaultChangeWindow / time.Second)
+		}
+	case AlertKindBubble:
+		if _, ok := CoinGoldGrams(a.Symbol); !ok {
+			return fmt.Errorf("%w: %s is not a gold coin", ErrInvalidAlert, a.Symbol)
+		}
+		if a.Condition == "" {
+			a.Condition = AlertAbove
+		}
+		if a.Condition != AlertAbove && a.Condition != AlertBelow {
+			return fmt.Errorf("%w: bubble alerts need condition above or below", ErrInvalidAlert)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidAlert, a.Kind)
+	}
+
+	switch a.Mode {
+	case "":
+		a.Mode = AlertOnce
+	case AlertOnce, AlertRecurring:
+	default:
+		return fmt.Errorf("%w: mode must be once or recurring", ErrInvalidAlert)
+	}
+	if a.CooldownSeconds < 0 {
+		return fmt.Errorf("%w: cooldown cannot be negative", ErrInvalidAlert)
+	}
+	if a.Mode == AlertRecurring && a.CooldownSeconds == 0 {
+		a.CooldownSeconds = int64(defaultAlertCooldown / time.Second)
+	}
+	return nil
+}
+
+// AlertEvent records one trigger of an alert.
+type AlertEvent struct {
+	ID          uint      `json:"id"`
+	AlertID     uint      `json:"alert_id"`
+	UserID      uint      `json:"user_id,omitempty"`
+	ChatID      int64     `json:"chat_id,omitempty"`
+	Symbol      string    `json:"symbol"`
+	Kind        string    `json:"kind"`
+	Condition   string    `json:"condition"`
+	Threshold   float64   `json:"threshold"`
+	Value       float64   `json:"value"` // Price, percent change or bubble percent that triggered the alert
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// API key scopes.
+const (
+	ScopeRead   = "read"   // REST queries
+	ScopeStream = "stream" // WebSocket stream
+	ScopeAdmin  = "admin"  // Management endpoints and writes
+)
+
+// APIKey grants access to the API. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the key, to recognise it
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`  // Requests per minute, 0 means unlimited
+	DailyQuota int64      `json:"daily_quota"` // Requests per day, 0 means unlimited
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope reports whether the key was granted scope. Admin keys hold every scope.
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyUsage is the request count of a key on one day.
+type APIKeyUsage struct {
+	Day      string `json:"day"` // YYYY-MM-DD
+	Requests int64  `json:"requests"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+// Candle aggregates the prices of a symbol over one interval.
+type Candle struct {
+	Symbol   string  `json:"symbol"`
+	TimeUnix int64   `json:"time_unix"` // Start of the interval
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Count    int     `json:"count"` // Recorded changes inside the interval, 0 for carried candles
+}
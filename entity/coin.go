@@ -0,0 +1,16 @@
+package entity
+
+// coinGoldGrams is the pure gold content of the coins quoted by the provider (0.900 fineness).
+var coinGoldGrams = map[string]float64{
+	"IR_COIN_EMAMI":   7.3224,
+	"IR_COIN_BAHAR":   7.3224,
+	"IR_COIN_HALF":    3.6612,
+	"IR_COIN_QUARTER": 1.8306,
+	"IR_COIN_1G":      0.9090,
+}
+
+// CoinGoldGrams returns the pure gold content of a gold coin; ok is false for other symbols.
+func CoinGoldGrams(symbol string) (grams float64, ok bool) {
+	grams, ok = coinGoldGrams[symbol]
+	return grams, ok
+}
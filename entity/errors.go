@@ -0,0 +1,12 @@
+package entity
+
+import "errors"
+
+// ErrNotFound is returned by repositories when a record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrAlreadyExists is returned by repositories when a unique value is already taken.
+var ErrAlreadyExists = errors.New("record already exists")
+
+// ErrInvalidAlert is returned when an alert or a configured alert rule is not valid.
+var ErrInvalidAlert = errors.New("invalid alert")
@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+// Transaction sides.
+const (
+	TransactionBuy  = "buy"
+	TransactionSell = "sell"
+)
+
+// Portfolio groups the holdings of a user, e.g. gold grams, coins, foreign cash and crypto.
+type Portfolio struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PortfolioTransaction is a buy or sell of a symbol. Holdings are derived from the transactions.
+type PortfolioTransaction struct {
+	ID          uint      `json:"id"`
+	PortfolioID uint      `json:"portfolio_id"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`     // Price per unit of quantity, in Unit
+	Unit        string    `json:"unit"`      // Defaults to IRT
+	PriceIRT    float64   `json:"price_irt"` // Price converted at the rates of ExecutedAt
+	PriceUSD    float64   `json:"price_usd"`
+	ExecutedAt  time.Time `json:"executed_at"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
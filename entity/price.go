@@ -25,3 +25,30 @@ type Price struct {
 	CreatedAt     time.Time   `json:"created_at"`
 	UpdatedAt     time.Time   `json:"updated_at"`
 }
+
+// Fields the latest prices can be sorted by.
+const (
+	SortSymbol        = "symbol"
+	SortPrice         = "price"
+	SortChangePercent = "change_percent"
+	SortUpdatedAt     = "updated_at"
+)
+
+// PriceFilter selects, orders and pages the latest prices.
+type PriceFilter struct {
+	Types   []string
+	Symbols []string
+	Search  string // part of the symbol or of the English or Persian name
+	Sort    string // one of the Sort fields, SortSymbol when empty
+	Desc    bool
+	After   *PriceCursor // continue after this row; nil for the first page
+}
+
+// PriceCursor is the position of a row in a sorted price list: its sort value and, for
+// rows with equal values, its id.
+type PriceCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// ChatSubscription subscribes a Telegram chat to triggered alerts.
+// An empty symbol list receives alerts for every symbol.
+type ChatSubscription struct {
+	ChatID    int64     `json:"chat_id"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,91 @@
+package entity
+
+import "strings"
+
+// Unit is the canonical code of the currency a price is quoted in.
+type Unit string
+
+const (
+	UnitIRT  Unit = "IRT" // Iranian Toman
+	UnitIRR  Unit = "IRR" // Iranian Rial
+	UnitUSD  Unit = "USD"
+	UnitEUR  Unit = "EUR"
+	UnitAED  Unit = "AED"
+	UnitTRY  Unit = "TRY"
+	UnitGBP  Unit = "GBP"
+	UnitUSDT Unit = "USDT"
+)
+
+// RialsPerToman is the fixed ratio between the two Iranian units.
+const RialsPerToman = 10
+
+var unitLabels = map[Unit][2]string{
+	UnitIRT:  {"Toman", "تومان"},
+	UnitIRR:  {"Rial", "ریال"},
+	UnitUSD:  {"Dollar", "دلار"},
+	UnitEUR:  {"Euro", "یورو"},
+	UnitAED:  {"Dirham", "درهم"},
+	UnitTRY:  {"Lira", "لیر"},
+	UnitGBP:  {"Pound", "پوند"},
+	UnitUSDT: {"Tether", "تتر"},
+}
+
+// unitAliases maps the free-form labels seen in the feed to canonical units.
+var unitAliases = map[string]Unit{
+	"تومان":  UnitIRT,
+	"تومن":   UnitIRT,
+	"toman":  UnitIRT,
+	"ریال":   UnitIRR,
+	"rial":   UnitIRR,
+	"دلار":   UnitUSD,
+	"dollar": UnitUSD,
+	"$":      UnitUSD,
+	"یورو":   UnitEUR,
+	"euro":   UnitEUR,
+	"درهم":   UnitAED,
+	"dirham": UnitAED,
+	"لیر":    UnitTRY,
+	"lira":   UnitTRY,
+	"پوند":   UnitGBP,
+	"pound":  UnitGBP,
+	"تتر":    UnitUSDT,
+	"tether": UnitUSDT,
+}
+
+// ParseUnit resolves a Persian or English label, or the code of a known unit, to a Unit.
+// Other codes are rejected, as prices could not be converted to them.
+func ParseUnit(s string) (Unit, bool) {
+	s = strings.TrimSpace(s)
+	if u, ok := unitAliases[strings.ToLower(s)]; ok {
+		return u, true
+	}
+	u := Unit(strings.ToUpper(s))
+	if _, ok := unitLabels[u]; ok {
+		return u, true
+	}
+	return "", false
+}
+
+// NormalizeUnit returns the canonical code for a label, keeping unknown labels untouched.
+func NormalizeUnit(s string) string {
+	if u, ok := ParseUnit(s); ok {
+		return string(u)
+	}
+	return strings.TrimSpace(s)
+}
+
+// LabelEn returns the English display label of the unit.
+func (u Unit) LabelEn() string {
+	if l, ok := unitLabels[u]; ok {
+		return l[0]
+	}
+	return string(u)
+}
+
+// LabelFa returns the Persian display label of the unit.
+func (u Unit) LabelFa() string {
+	if l, ok := unitLabels[u]; ok {
+		return l[1]
+	}
+	return string(u)
+}
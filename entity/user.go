@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// User owns personal resources such as alerts, watchlists and portfolios.
+type User struct {
+	ID           uint      `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RefreshToken is a long-lived token exchanged for new access tokens.
+// Only the SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID        uint
+	UserID    uint
+	Hash      string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
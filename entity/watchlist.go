@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Watchlist is a named set of symbols, possibly of different types, owned by a user.
+type Watchlist struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Name      string    `json:"name"`
+	Symbols   []string  `json:"symbols"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
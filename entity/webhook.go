@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Webhook events.
+const (
+	EventPriceChanged   = "price.changed"
+	EventAlertTriggered = "alert.triggered"
+)
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // Retries exhausted
+)
+
+// Webhook is an outbound endpoint notified about price changes and alerts.
+// Empty filters match everything.
+type Webhook struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Only returned when the webhook is created
+	Events    []string  `json:"events"`
+	Symbols   []string  `json:"symbols"`
+	Types     []string  `json:"types"`
+	AlertIDs  []uint    `json:"alert_ids"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is one queued POST to a webhook and its delivery state.
+type WebhookDelivery struct {
+	ID            uint       `json:"id"`
+	WebhookID     uint       `json:"webhook_id"`
+	Event         string     `json:"event"`
+	Payload       string     `json:"payload"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
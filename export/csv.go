@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+type csvWriter struct {
+	cols   []Column
+	w      *csv.Writer
+	record []string
+}
+
+// newCSVWriter writes the header right away; values are buffered until Flush or Close.
+func newCSVWriter(w io.Writer, cols []Column) (*csvWriter, error) {
+	cw := &csvWriter{cols: cols, w: csv.NewWriter(w), record: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.record[i] = c.Name
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(row []interface{}) error {
+	if err := checkRow(cw.cols, row); err != nil {
+		return err
+	}
+	for i, v := range row {
+		cw.record[i] = formatValue(v)
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+// formatValue renders a value as text, floats in their shortest exact form.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Package export writes tabular data as CSV, XLSX or Parquet one row at a time,
+// so large exports never have to be held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSV     Format = "csv"
+	XLSX    Format = "xlsx"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat validates a format name, defaulting to CSV.
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return CSV, true
+	case CSV, XLSX, Parquet:
+		return f, true
+	}
+	return "", false
+}
+
+// ContentType is the media type of files in the format.
+func (f Format) ContentType() string {
+	switch f {
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension is the file name extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Kind is the type of the values in a column.
+type Kind int
+
+const (
+	String Kind = iota // string
+	Int                // int64
+	Float              // float64
+)
+
+// Column describes one column of the output.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Writer writes rows whose values match the kinds of its columns, in column order.
+// Close must be called to complete the file; it does not close the underlying writer.
+type Writer interface {
+	Write(row []interface{}) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter returns a writer for the format that writes to w.
+func NewWriter(f Format, w io.Writer, cols []Column) (Writer, error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w, cols)
+	case XLSX:
+		return newXLSXWriter(w, cols)
+	case Parquet:
+		return newParquetWriter(w, cols)
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// checkRow reports a row that does not match the columns.
+func checkRow(cols []Column, row []interface{}) error {
+	if len(row) != len(cols) {
+		return fmt.Errorf("export row has %d values for %d columns", len(row), len(cols))
+	}
+	for i, c := range cols {
+		ok := false
+		switch row[i].(type) {
+		case string:
+			ok = c.Kind == String
+		case int64:
+			ok = c.Kind == Int
+		case float64:
+			ok = c.Kind == Float
+		}
+		if !ok {
+			return fmt.Errorf("export column %s: unexpected value %T", c.Name, row[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRowGroup is the number of rows buffered before a row group is written out.
+const parquetRowGroup = 10000
+
+type parquetWriter struct {
+	cols    []Column
+	w       *parquet.Writer
+	leaf    []int // leaf column index of every column; the schema orders them by name
+	rows    []parquet.Row
+	pending int
+}
+
+func newParquetWriter(w io.Writer, cols []Column) (*parquetWriter, error) {
+	group := make(parquet.Group, len(cols))
+	for _, c := range cols {
+		switch c.Kind {
+		case Int:
+			group[c.Name] = parquet.Leaf(parquet.Int64Type)
+		case Float:
+			group[c.Name] = parquet.Leaf(parquet.DoubleType)
+		default:
+			group[c.Name] = parquet.String()
+		}
+	}
+	schema := parquet.NewSchema("export", group)
+
+	pw := &parquetWriter{
+		cols: cols,
+		w:    parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy)),
+		leaf: make([]int, len(cols)),
+		rows: make([]parquet.Row, 0, 256),
+	}
+	for i, c := range cols {
+		col, _ := schema.Lookup(c.Name)
+		pw.leaf[i] = col.ColumnIndex
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) Write(row []interface{}) error {
+	if err := checkRow(pw.cols, row); err != nil {
+		return err
+	}
+	values := make(parquet.Row, len(row))
+	for i, v := range row {
+		var value parquet.Value
+		switch v := v.(type) {
+		case string:
+			value = parquet.ByteArrayValue([]byte(v))
+		case int64:
+			value = parquet.Int64Value(v)
+		case float64:
+			value = parquet.DoubleValue(v)
+		}
+		values[pw.leaf[i]] = value.Level(0, 0, pw.leaf[i])
+	}
+	pw.rows = append(pw.rows, values)
+	if len(pw.rows) == cap(pw.rows) {
+		return pw.writeRows()
+	}
+	return nil
+}
+
+func (pw *parquetWriter) writeRows() error {
+	n, err := pw.w.WriteRows(pw.rows)
+	pw.pending += n
+	pw.rows = pw.rows[:0]
+	if err != nil {
+		return err
+	}
+	if pw.pending >= parquetRowGroup {
+		pw.pending = 0
+		return pw.w.Flush()
+	}
+	return nil
+}
+
+// Flush hands the buffered rows to the parquet writer. Row groups are only ended
+// once they hold parquetRowGroup rows, so frequent flushes do not fragment the file.
+func (pw *parquetWriter) Flush() error {
+	if len(pw.rows) == 0 {
+		return nil
+	}
+	return pw.writeRows()
+}
+
+func (pw *parquetWriter) Close() error {
+	if err := pw.Flush(); err != nil {
+		return err
+	}
+	return pw.w.Close()
+}
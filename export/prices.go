@@ -0,0 +1,85 @@
+package export
+
+import (
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// Options select the optional columns of price exports.
+type Options struct {
+	Names  bool // name_en and name_fa
+	Jalali bool // the Jalali date and clock in Tehran time
+}
+
+// HistoryColumns are the columns of history exports. Without options the header is
+// the one the import command recognises as an export file.
+func HistoryColumns(o Options) []Column {
+	cols := []Column{
+		{"symbol", String}, {"type", String}, {"unit", String},
+		{"time_unix", Int}, {"time", String}, {"price", Float},
+	}
+	return append(cols, optionalColumns(o)...)
+}
+
+// HistoryRow is the row of a recorded price; p carries the names and unit of its symbol.
+func HistoryRow(p entity.Price, o Options) []interface{} {
+	price, _ := p.Price.Float64()
+	row := []interface{}{p.Symbol, p.Type, p.Unit, p.TimeUnix, formatTime(p.TimeUnix), price}
+	return append(row, optionalValues(p, p.TimeUnix, o)...)
+}
+
+// CandleColumns are the columns of candle exports.
+func CandleColumns(o Options) []Column {
+	cols := []Column{
+		{"symbol", String}, {"type", String}, {"unit", String},
+		{"time_unix", Int}, {"time", String},
+		{"open", Float}, {"high", Float}, {"low", Float}, {"close", Float}, {"count", Int},
+	}
+	return append(cols, optionalColumns(o)...)
+}
+
+// CandleRow is the row of a candle; meta is the latest price of its symbol.
+func CandleRow(meta entity.Price, c entity.Candle, o Options) []interface{} {
+	row := []interface{}{c.Symbol, meta.Type, meta.Unit, c.TimeUnix, formatTime(c.TimeUnix),
+		c.Open, c.High, c.Low, c.Close, int64(c.Count)}
+	return append(row, optionalValues(meta, c.TimeUnix, o)...)
+}
+
+// Header returns the names of the columns.
+func Header(cols []Column) []string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	return names
+}
+
+func optionalColumns(o Options) []Column {
+	var cols []Column
+	if o.Names {
+		cols = append(cols, Column{"name_en", String}, Column{"name_fa", String})
+	}
+	if o.Jalali {
+		cols = append(cols, Column{"jalali", String})
+	}
+	return cols
+}
+
+func optionalValues(p entity.Price, ts int64, o Options) []interface{} {
+	var values []interface{}
+	if o.Names {
+		values = append(values, p.NameEn, p.NameFa)
+	}
+	if o.Jalali {
+		t := time.Unix(ts, 0)
+		values = append(values, calendar.FormatDate(t, calendar.Jalali)+" "+calendar.FormatTime(t))
+	}
+	return values
+}
+
+// formatTime renders a timestamp as RFC 3339 in Tehran time.
+func formatTime(ts int64) string {
+	return time.Unix(ts, 0).In(calendar.Tehran()).Format(time.RFC3339)
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxSheetRows is the row limit of an Excel worksheet; longer exports continue
+// on further sheets, each starting with the header.
+const maxSheetRows = 1 << 20
+
+// xlsxWriter streams the worksheets into the zip archive as rows arrive and
+// writes the workbook parts that list them on Close.
+type xlsxWriter struct {
+	cols   []Column
+	zw     *zip.Writer
+	sheet  *bufio.Writer
+	sheets int
+	rows   int // rows of the current sheet, including the header
+}
+
+func newXLSXWriter(w io.Writer, cols []Column) (*xlsxWriter, error) {
+	xw := &xlsxWriter{cols: cols, zw: zip.NewWriter(w)}
+	if err := xw.startSheet(); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (xw *xlsxWriter) startSheet() error {
+	xw.sheets++
+	f, err := xw.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", xw.sheets))
+	if err != nil {
+		return err
+	}
+	xw.sheet = bufio.NewWriter(f)
+	xw.rows = 0
+	xw.sheet.WriteString(xml.Header)
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(xw.cols))
+	for i, c := range xw.cols {
+		header[i] = c.Name
+	}
+	return xw.writeRow(header)
+}
+
+func (xw *xlsxWriter) endSheet() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+	return xw.sheet.Flush()
+}
+
+func (xw *xlsxWriter) Write(row []interface{}) error {
+	if err := checkRow(xw.cols, row); err != nil {
+		return err
+	}
+	if xw.rows == maxSheetRows {
+		if err := xw.endSheet(); err != nil {
+			return err
+		}
+		if err := xw.startSheet(); err != nil {
+			return err
+		}
+	}
+	return xw.writeRow(row)
+}
+
+// writeRow writes numbers as values and text as inline strings, so no shared string table is needed.
+func (xw *xlsxWriter) writeRow(row []interface{}) error {
+	xw.rows++
+	fmt.Fprintf(xw.sheet, `<row r="%d">`, xw.rows)
+	for _, v := range row {
+		if s, ok := v.(string); ok {
+			xw.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(xw.sheet, []byte(s)); err != nil {
+				return err
+			}
+			xw.sheet.WriteString(`</t></is></c>`)
+			continue
+		}
+		fmt.Fprintf(xw.sheet, `<c><v>%s</v></c>`, formatValue(v))
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxWriter) Flush() error {
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.zw.Flush()
+}
+
+func (xw *xlsxWriter) Close() error {
+	if err := xw.endSheet(); err != nil {
+		return err
+	}
+
+	var types, sheets, rels strings.Builder
+	for i := 1; i <= xw.sheets; i++ {
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+		fmt.Fprintf(&sheets, `<sheet name="Sheet%d" sheetId="%d" r:id="rId%d"/>`, i, i, i)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, p := range parts {
+		f, err := xw.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+p.body); err != nil {
+			return err
+		}
+	}
+	return xw.zw.Close()
+}
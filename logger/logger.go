@@ -0,0 +1,105 @@
+// Package logger configures structured logging and carries correlation IDs through contexts.
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+type fetchIDKey struct{}
+
+// New builds a logger writing to w. format is "json" or "text", level is one of debug,
+// info, warn or error. Records logged with a context get its request, fetch and trace IDs.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text", "":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, use json or text", format)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Init installs a logger writing to w as the slog default, which the standard log package also uses.
+func Init(w io.Writer, format, level string) error {
+	l, err := New(w, format, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(l)
+	return nil
+}
+
+// NewID returns a random 16 character hex ID.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WithRequestID returns a context whose log records carry the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithFetchID returns a context whose log records carry the fetch cycle ID.
+func WithFetchID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, fetchIDKey{}, id)
+}
+
+// FetchID returns the fetch cycle ID of ctx, or "".
+func FetchID(ctx context.Context) string {
+	id, _ := ctx.Value(fetchIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the correlation IDs found in the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id := FetchID(ctx); id != "" {
+			r.AddAttrs(slog.String("fetch_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,56 +1,64 @@
 package main
 
 import (
-	"log"
-	"net/http"
-	"time"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 
-	db "github.com/ar-mokhtari/market-tracker/adapter/storage"
 	config "github.com/ar-mokhtari/market-tracker/config"
-	delivery "github.com/ar-mokhtari/market-tracker/delivery/http"
-	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
-	"github.com/rs/cors"
+	"github.com/ar-mokhtari/market-tracker/logger"
+	"github.com/ar-mokhtari/market-tracker/tracing"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "help", "-h", "--help":
+			fmt.Print(usage)
+			return
+		case "doctor":
+			// doctor loads the configuration itself, so an invalid one is reported as a failed check
+			exit(doctor(os.Args[2:]))
+		}
+	}
+
 	// 1. Initialize Configuration
 	cfg := config.Init()
+	if err := logger.Init(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		slog.Error("invalid logging configuration", "err", err)
+		os.Exit(1)
+	}
 
-	// 2. Initialize Database
-	database := db.Init(cfg.DBDSN)
-	defer database.Close()
-
-	// 3. Initialize Delivery
-	// Then pass this hub to your delivery layer
-	// Note: You need to update your delivery.Init to accept the hub
-
-	// Inside main() after initializing database and before delivery.Init
-	hub := v1.NewHub()
-	go hub.Run()
-	mux := delivery.Init(database, cfg, hub)
-
-	// 4. CORS Setup
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		AllowCredentials: true,
-	})
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.OTLPEndpoint != "" {
+		shutdown, err := tracing.Init(context.Background(), cfg.Tracing.OTLPEndpoint)
+		if err != nil {
+			slog.Error("tracing setup failed", "err", err)
+			os.Exit(1)
+		}
+		shutdownTracing = shutdown
+		slog.Info("tracing enabled", "endpoint", cfg.Tracing.OTLPEndpoint)
+	}
 
-	// Wrap mux with CORS handler
-	handler := c.Handler(mux)
+	// 2. Run the requested command, the server by default
+	err := runCommand(cfg, os.Args[1:])
+	_ = shutdownTracing(context.Background())
+	exit(err)
+}
 
-	// 5. Start Server with Handler
-	server := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+// exit reports the error of a command, printing the usage for command line mistakes, and
+// exits with the matching status.
+func exit(err error) {
+	var ue usageError
+	if errors.As(err, &ue) {
+		fmt.Fprintf(os.Stderr, "%s\n\n%s", ue, usage)
+		os.Exit(2)
 	}
-
-	currentTime := time.Now().Format("2006/01/02 15:04:05")
-	log.Printf("%s 🚀 Server starting on port %s", currentTime, cfg.Port)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failure: %v", err)
+	if err != nil {
+		slog.Error("command failed", "args", os.Args[1:], "err", err)
+		os.Exit(1)
 	}
+	os.Exit(0)
 }
@@ -0,0 +1,147 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "market_tracker"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	fetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "fetch_duration_seconds",
+		Help:      "Duration of upstream price fetch cycles.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30},
+	}, []string{"provider"})
+
+	fetchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fetch_total",
+		Help:      "Upstream price fetch cycles by result (success or failure).",
+	}, []string{"provider", "result"})
+
+	symbolsUpdated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "fetch_symbols_updated",
+		Help:      "Number of symbols updated by the last fetch cycle.",
+	}, []string{"provider"})
+
+	upsertDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "price_upsert_duration_seconds",
+		Help:      "Latency of storing one price and its history row.",
+		Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1},
+	})
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		fetchDuration, fetchTotal, symbolsUpdated, upsertDuration, httpRequests, httpDuration,
+	)
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exposes the connection pool statistics of db.
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterWebsocketClients exposes the number of connected websocket clients.
+func RegisterWebsocketClients(count func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "websocket_clients",
+		Help:      "Number of connected websocket clients.",
+	}, func() float64 { return float64(count()) }))
+}
+
+// ObserveFetch records one fetch cycle of a provider.
+func ObserveFetch(provider string, d time.Duration, updated int, err error) {
+	fetchDuration.WithLabelValues(provider).Observe(d.Seconds())
+	if err != nil {
+		fetchTotal.WithLabelValues(provider, "failure").Inc()
+		return
+	}
+	fetchTotal.WithLabelValues(provider, "success").Inc()
+	symbolsUpdated.WithLabelValues(provider).Set(float64(updated))
+}
+
+// ObserveUpsert records the latency of one price upsert.
+func ObserveUpsert(d time.Duration) {
+	upsertDuration.Observe(d.Seconds())
+}
+
+// Middleware counts requests and their latency per route. The route is the mux pattern
+// that matches the request, so path values such as IDs do not create new series.
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.code)).Inc()
+	})
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Hijack hands the connection over to the websocket upgrader.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not implement http.Hijacker")
+	}
+	s.code = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
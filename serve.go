@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	config "github.com/ar-mokhtari/market-tracker/config"
+	delivery "github.com/ar-mokhtari/market-tracker/delivery/http"
+	v1 "github.com/ar-mokhtari/market-tracker/delivery/http/v1"
+	"github.com/rs/cors"
+)
+
+// shutdownTimeout is how long running requests may take to finish when the server stops.
+const shutdownTimeout = 10 * time.Second
+
+// serve starts the HTTP server with the fetcher and every background worker.
+func serve(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return usageError("serve takes no arguments")
+	}
+
+	// 1. Initialize Database
+	database := openDB(cfg, true)
+	defer database.Close()
+
+	// 2. Initialize Delivery with the WebSocket hub
+	hub := v1.NewHub()
+	go hub.Run()
+
+	// The configuration file, when used, is watched for changes and SIGHUP
+	var watcher *config.Watcher
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		watcher = config.NewWatcher(path, cfg)
+		go watcher.Run(context.Background())
+	}
+	mux, grpcAPI := delivery.Init(database, cfg, hub, watcher)
+
+	// 3. CORS Setup; allowed origins follow configuration reloads
+	var origins atomic.Pointer[[]string]
+	origins.Store(&cfg.Server.CORSOrigins)
+	watcher.OnReload(func(c *config.Config) { origins.Store(&c.Server.CORSOrigins) })
+	c := cors.New(cors.Options{
+		AllowOriginFunc: func(origin string) bool {
+			allowed := *origins.Load()
+			return slices.Contains(allowed, "*") || slices.Contains(allowed, origin)
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key", "X-Request-ID", "If-None-Match", "If-Modified-Since"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After", "X-Request-ID", "ETag", "Last-Modified"},
+	})
+
+	// 4. Start Server with Handler
+	server := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      c.Handler(mux),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+	}
+
+	// SIGINT and SIGTERM stop the HTTP server and the gRPC API together
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The gRPC port is opened first, so a port in use fails the start
+	grpcErr := make(chan error, 1)
+	if grpcAPI != nil {
+		lis, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			return fmt.Errorf("grpc listen: %w", err)
+		}
+		go func() {
+			slog.Info("grpc server starting", "port", cfg.Server.GRPCPort)
+			grpcErr <- grpcAPI.Serve(ctx, lis)
+		}()
+	}
+	httpErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", cfg.Server.Port, "fetch_interval_minutes", cfg.Provider.FetchInterval)
+		httpErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-httpErr:
+		return err
+	case err := <-grpcErr:
+		return fmt.Errorf("grpc server: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("server stopping")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if grpcAPI != nil {
+		if grpcStopErr := <-grpcErr; err == nil {
+			err = grpcStopErr
+		}
+	}
+	return err
+}
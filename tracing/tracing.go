@@ -0,0 +1,84 @@
+// Package tracing configures OpenTelemetry tracing and exports spans over OTLP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/ar-mokhtari/market-tracker"
+	serviceName         = "market-tracker"
+)
+
+// Tracer returns the tracer used for the application's own spans.
+// It is a no-op until Init or Setup installs a provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Init exports spans to the OTLP/HTTP endpoint, e.g. http://localhost:4318 for a local collector.
+// As with OTEL_EXPORTER_OTLP_ENDPOINT, /v1/traces is appended to the base URL. The sampler and
+// the remaining exporter settings honour the standard OTEL_* variables.
+// The returned function flushes pending spans and must be called before exiting.
+func Init(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	if err != nil {
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	return Setup(sdktrace.WithBatcher(exporter))
+}
+
+// Setup installs a tracer provider built from opts as the global provider. Tests can pass
+// sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) to inspect the recorded spans.
+func Setup(opts ...sdktrace.TracerProviderOption) (func(context.Context) error, error) {
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(context.Background(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(), resource.WithTelemetrySDK(), resource.WithHost())
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(append(opts, sdktrace.WithResource(res))...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+// Middleware starts a server span for every request, named after the mux pattern that
+// matches it, and continues traces propagated by the caller.
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		switch {
+		case pattern == "":
+			return r.Method + " unmatched"
+		case strings.HasPrefix(pattern, "/"):
+			return r.Method + " " + pattern
+		}
+		return pattern
+	}))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown, err := Setup(sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	// The handler calls a use case that records its own span, as the fetcher and the repository do
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/prices/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := Tracer().Start(r.Context(), "Repo.GetLatest")
+		End(span, errors.New("not found"))
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := Middleware(mux, mux)
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	tests := []struct {
+		name        string
+		path        string
+		traceparent string
+		wantName    string
+		wantChild   bool
+	}{
+		{"matched route", "/api/v1/prices/USD", "", "GET /api/v1/prices/{symbol}", true},
+		{"propagated trace", "/api/v1/prices/USD", parent, "GET /api/v1/prices/{symbol}", true},
+		{"unmatched route", "/nope", "", "GET unmatched", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			server := spans[len(spans)-1]
+			if server.Name != tt.wantName || server.SpanKind != trace.SpanKindServer {
+				t.Errorf("server span = %q (%v), want %q", server.Name, server.SpanKind, tt.wantName)
+			}
+			if tt.traceparent != "" {
+				if got := server.Parent.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" || !server.Parent.IsRemote() {
+					t.Errorf("server span parent trace = %s, want the caller's", got)
+				}
+			} else if server.Parent.IsValid() {
+				t.Error("server span has a parent without traceparent")
+			}
+
+			if !tt.wantChild {
+				if len(spans) != 1 {
+					t.Errorf("got %d spans, want only the server span", len(spans))
+				}
+				return
+			}
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want the use case span and the server span", len(spans))
+			}
+			child := spans[0]
+			if child.Parent.SpanID() != server.SpanContext.SpanID() || child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+				t.Error("use case span is not a child of the server span")
+			}
+			if child.Status.Code != codes.Error || len(child.Events) == 0 {
+				t.Errorf("use case span status = %v with %d events, want the recorded error", child.Status.Code, len(child.Events))
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// ErrInvalidAlert is entity.ErrInvalidAlert, which the alert rules return.
+var ErrInvalidAlert = entity.ErrInvalidAlert
+
+// AllUsers gives administrators access to the alerts of every user.
+const AllUsers = ^uint(0)
+
+const maxAlertEventsPerList = 100
+
+type AlertUseCase struct {
+	repo      AlertRepo
+	prices    Repo
+	mu        sync.RWMutex
+	notifiers []Notifier
+
+	// Rules are global alerts from the configuration. They are evaluated with the
+	// stored alerts but never persisted; their last triggers are kept in memory.
+	rules     []entity.Alert
+	ruleFired map[string]time.Time // Last trigger by ruleKey, kept across reloads
+}
+
+func NewAlertUseCase(repo AlertRepo, prices Repo) *AlertUseCase {
+	return &AlertUseCase{repo: repo, prices: prices}
+}
+
+// AddNotifier registers a channel that receives every triggered alert.
+func (a *AlertUseCase) AddNotifier(n Notifier) {
+	a.mu.Lock()
+	a.notifiers = append(a.notifiers, n)
+	a.mu.Unlock()
+}
+
+// Create validates an alert, fills in defaults and stores it.
+func (a *AlertUseCase) Create(ctx context.Context, alert *entity.Alert) error {
+	if err := normalizeAlert(alert); err != nil {
+		return err
+	}
+	return a.repo.CreateAlert(ctx, alert)
+}
+
+// SetRules replaces the configured global alert rules. Rules always recur after
+// their cooldown. Nothing is replaced when any rule is invalid. Rules that are kept
+// unchanged keep their last trigger, so a reload does not fire them again.
+func (a *AlertUseCase) SetRules(rules []entity.Alert) error {
+	normalized := make([]entity.Alert, len(rules))
+	for i, rule := range rules {
+		rule.Mode = entity.AlertRecurring
+		if err := normalizeAlert(&rule); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+		normalized[i] = rule
+	}
+	a.mu.Lock()
+	fired := make(map[string]time.Time, len(normalized))
+	for _, rule := range normalized {
+		key := ruleKey(rule)
+		if at, ok := a.ruleFired[key]; ok {
+			fired[key] = at
+		}
+	}
+	a.rules = normalized
+	a.ruleFired = fired
+	a.mu.Unlock()
+	return nil
+}
+
+// ruleKey identifies a configured rule by its definition, as rules have no ID.
+func ruleKey(rule entity.Alert) string {
+	return fmt.Sprintf("%s|%s|%s|%g|%s|%d|%d", rule.Symbol, rule.Kind, rule.Condition, rule.Threshold,
+		rule.Unit, rule.WindowSeconds, rule.CooldownSeconds)
+}
+
+// Get returns an alert of userID, or of any user for AllUsers. The alerts without owner
+// belong to user 0.
+func (a *AlertUseCase) Get(ctx context.Context, id, userID uint) (entity.Alert, error) {
+	alert, err := a.repo.GetAlert(ctx, id)
+	if err == nil && userID != AllUsers && alert.UserID != userID {
+		return entity.Alert{}, entity.ErrNotFound
+	}
+	return alert, err
+}
+
+// List returns the alerts of a user, or every alert for AllUsers.
+func (a *AlertUseCase) List(ctx context.Context, userID uint) ([]entity.Alert, error) {
+	if userID == AllUsers {
+		return a.repo.ListAlerts(ctx, false)
+	}
+	return a.repo.ListUserAlerts(ctx, userID)
+}
+
+func (a *AlertUseCase) Delete(ctx context.Context, id, userID uint) error {
+	if _, err := a.Get(ctx, id, userID); err != nil {
+		return err
+	}
+	return a.repo.DeleteAlert(ctx, id)
+}
+
+// Events returns the latest triggers of an alert.
+func (a *AlertUseCase) Events(ctx context.Context, id, userID uint) ([]entity.AlertEvent, error) {
+	if _, err := a.Get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return a.repo.ListAlertEvents(ctx, id, maxAlertEventsPerList)
+}
+
+func normalizeAlert(alert *entity.Alert) error {
+	if err := alert.Normalize(); err != nil {
+		return err
+	}
+	alert.Active = true
+	return nil
+}
+
+// Evaluate checks every active alert and configured rule against the prices of a
+// fetch cycle, records and delivers the ones that trigger.
+func (a *AlertUseCase) Evaluate(ctx context.Context, prices []entity.Price) []entity.AlertEvent {
+	alerts, err := a.repo.ListAlerts(ctx, true)
+	if err != nil {
+		slog.ErrorContext(ctx, "alert evaluation failed", "err", err)
+	}
+
+	a.mu.RLock()
+	stored := len(alerts)
+	for _, rule := range a.rules {
+		if at, ok := a.ruleFired[ruleKey(rule)]; ok {
+			rule.LastTriggeredAt = &at
+		}
+		alerts = append(alerts, rule)
+	}
+	a.mu.RUnlock()
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	current := make(map[string]entity.Price, len(prices))
+	values := make(map[string]float64, len(prices))
+	for _, p := range prices {
+		current[p.Symbol] = p
+		if v, err := p.Price.Float64(); err == nil {
+			values[p.Symbol] = v
+		}
+	}
+	graph := buildRateGraph(prices)
+	references := make(map[int64]map[string]float64)
+
+	var events []entity.AlertEvent
+	for i, alert := range alerts {
+		if alert.LastTriggeredAt != nil && now.Sub(*alert.LastTriggeredAt) < time.Duration(alert.CooldownSeconds)*time.Second {
+			continue
+		}
+
+		value, ok := a.alertValue(ctx, alert, current, values, graph, references, now)
+		if !ok || !alertTriggered(alert, value) {
+			continue
+		}
+
+		event := entity.AlertEvent{
+			AlertID:     alert.ID,
+			UserID:      alert.UserID,
+			ChatID:      alert.ChatID,
+			Symbol:      alert.Symbol,
+			Kind:        alert.Kind,
+			Condition:   alert.Condition,
+			Threshold:   alert.Threshold,
+			Value:       value,
+			Message:     alertMessage(alert, value),
+			TriggeredAt: now,
+		}
+		if i >= stored {
+			a.mu.Lock()
+			a.ruleFired[ruleKey(alert)] = now
+			a.mu.Unlock()
+		} else {
+			if err := a.repo.SaveAlertEvent(ctx, &event); err != nil {
+				slog.ErrorContext(ctx, "alert event save failed", "alert_id", alert.ID, "err", err)
+			}
+			if err := a.repo.MarkAlertTriggered(ctx, alert.ID, now, alert.Mode == entity.AlertOnce); err != nil {
+				slog.ErrorContext(ctx, "alert update failed", "alert_id", alert.ID, "err", err)
+			}
+		}
+		a.notify(ctx, event)
+		events = append(events, event)
+	}
+	return events
+}
+
+// alertValue returns the number an alert compares against its threshold.
+func (a *AlertUseCase) alertValue(ctx context.Context, alert entity.Alert, current map[string]entity.Price, values map[string]float64,
+	graph map[string][]rateEdge, references map[int64]map[string]float64, now time.Time) (float64, bool) {
+	price, ok := values[alert.Symbol]
+	if !ok {
+		return 0, false
+	}
+
+	switch alert.Kind {
+	case entity.AlertKindPrice:
+		if alert.Unit == "" {
+			return price, true
+		}
+		steps, err := findPath(graph, entity.NormalizeUnit(current[alert.Symbol].Unit), alert.Unit)
+		if err != nil {
+			return 0, false
+		}
+		for _, st := range steps {
+			price *= st.Rate
+		}
+		return price, true
+
+	case entity.AlertKindChange:
+		ref, ok := references[alert.WindowSeconds]
+		if !ok {
+			past, err := a.prices.GetPricesAt(ctx, now.Add(-time.Duration(alert.WindowSeconds)*time.Second))
+			if err != nil {
+				slog.ErrorContext(ctx, "alert reference prices failed", "err", err)
+				return 0, false
+			}
+			ref = make(map[string]float64, len(past))
+			for _, p := range past {
+				if v, err := p.Price.Float64(); err == nil {
+					ref[p.Symbol] = v
+				}
+			}
+			references[alert.WindowSeconds] = ref
+		}
+		base := ref[alert.Symbol]
+		if base <= 0 {
+			return 0, false
+		}
+		return (price - base) / base * 100, true
+
+	case entity.AlertKindBubble:
+		return CoinBubble(alert.Symbol, values)
+	}
+	return 0, false
+}
+
+func alertTriggered(alert entity.Alert, value float64) bool {
+	switch alert.Condition {
+	case entity.AlertAbove:
+		return value >= alert.Threshold
+	case entity.AlertBelow:
+		return value <= alert.Threshold
+	case entity.AlertUp:
+		return value >= alert.Threshold
+	case entity.AlertDown:
+		return value <= -alert.Threshold
+	case entity.AlertAny:
+		return value >= alert.Threshold || value <= -alert.Threshold
+	}
+	return false
+}
+
+func alertMessage(alert entity.Alert, value float64) string {
+	switch alert.Kind {
+	case entity.AlertKindChange:
+		return fmt.Sprintf("%s moved %+.2f%% in %s (threshold %.2f%%)", alert.Symbol, value,
+			time.Duration(alert.WindowSeconds)*time.Second, alert.Threshold)
+	case entity.AlertKindBubble:
+		return fmt.Sprintf("%s bubble is %.2f%%, %s %.2f%%", alert.Symbol, value, alert.Condition, alert.Threshold)
+	}
+	unit := alert.Unit
+	if unit != "" {
+		unit = " " + unit
+	}
+	return fmt.Sprintf("%s is %s %g%s (current %g%s)", alert.Symbol, alert.Condition, alert.Threshold, unit, value, unit)
+}
+
+func (a *AlertUseCase) notify(ctx context.Context, event entity.AlertEvent) {
+	a.mu.RLock()
+	notifiers := a.notifiers
+	a.mu.RUnlock()
+
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, event); err != nil {
+			slog.WarnContext(ctx, "alert notify failed", "alert_id", event.AlertID, "err", err)
+		}
+	}
+}
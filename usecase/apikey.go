@@ -0,0 +1,319 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrUnauthorized  = errors.New("missing or invalid credentials")
+	ErrForbidden     = errors.New("credentials lack the required scope")
+	ErrRateLimited   = errors.New("rate limit exceeded")
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+)
+
+const (
+	apiKeyPrefix      = "mt_"
+	apiKeyCacheTTL    = time.Minute
+	apiKeyFlushPeriod = 10 * time.Second
+
+	// userRateLimit is the requests per minute of a signed-in user on their own resources
+	userRateLimit = 120
+)
+
+// RateStatus describes the limiter state after a request was counted.
+type RateStatus struct {
+	Limit      int           // Requests per minute, 0 when unlimited
+	Remaining  int           // Requests left in the current bucket
+	RetryAfter time.Duration // Set when the request was rejected
+}
+
+type APIKeyUseCase struct {
+	repo APIKeyRepo
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey // By key hash
+	buckets map[uint]*tokenBucket
+	users   map[uint]*tokenBucket // By user ID
+	day     string
+	used    map[uint]int64 // Requests today, flushed and pending
+	loaded  map[uint]bool  // Whether today's stored count was read
+	pending map[uint]int64 // Requests not yet written to the database
+}
+
+type cachedKey struct {
+	key     entity.APIKey
+	expires time.Time
+}
+
+func NewAPIKeyUseCase(repo APIKeyRepo) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		repo:    repo,
+		cache:   make(map[string]cachedKey),
+		buckets: make(map[uint]*tokenBucket),
+		users:   make(map[uint]*tokenBucket),
+		used:    make(map[uint]int64),
+		loaded:  make(map[uint]bool),
+		pending: make(map[uint]int64),
+	}
+}
+
+// Create generates a new key. The raw key is returned once and never stored.
+func (a *APIKeyUseCase) Create(ctx context.Context, k *entity.APIKey) (string, error) {
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(k.Scopes) == 0 {
+		k.Scopes = []string{entity.ScopeRead}
+	}
+	for i, s := range k.Scopes {
+		k.Scopes[i] = strings.ToLower(strings.TrimSpace(s))
+		if !slices.Contains([]string{entity.ScopeRead, entity.ScopeStream, entity.ScopeAdmin}, k.Scopes[i]) {
+			return "", fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, s)
+		}
+	}
+	if k.RateLimit < 0 || k.DailyQuota < 0 {
+		return "", fmt.Errorf("%w: limits cannot be negative", ErrInvalidAPIKey)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	raw := apiKeyPrefix + hex.EncodeToString(secret)
+	k.Prefix = raw[:len(apiKeyPrefix)+8]
+	k.Hash = hashSecret(raw)
+	k.Active = true
+
+	if err := a.repo.CreateAPIKey(ctx, k); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func (a *APIKeyUseCase) List(ctx context.Context) ([]entity.APIKey, error) {
+	return a.repo.ListAPIKeys(ctx)
+}
+
+// Revoke deactivates a key; cached copies expire within apiKeyCacheTTL.
+func (a *APIKeyUseCase) Revoke(ctx context.Context, id uint) error {
+	if err := a.repo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	for h, c := range a.cache {
+		if c.key.ID == id {
+			delete(a.cache, h)
+		}
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+// Usage returns the daily request counters of a key, including unflushed requests.
+func (a *APIKeyUseCase) Usage(ctx context.Context, id uint, days int) ([]entity.APIKeyUsage, error) {
+	if _, err := a.repo.GetAPIKey(ctx, id); err != nil {
+		return nil, err
+	}
+	a.Flush(ctx)
+	return a.repo.ListAPIKeyUsage(ctx, id, days)
+}
+
+// IsAPIKey reports whether a bearer credential looks like an API key rather than a user token.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(strings.TrimSpace(raw), apiKeyPrefix)
+}
+
+// Authenticate resolves a raw key to an active API key.
+func (a *APIKeyUseCase) Authenticate(ctx context.Context, raw string) (entity.APIKey, error) {
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return entity.APIKey{}, ErrUnauthorized
+	}
+	hash := hashSecret(raw)
+
+	a.mu.Lock()
+	c, ok := a.cache[hash]
+	a.mu.Unlock()
+	if ok && time.Now().Before(c.expires) {
+		if !c.key.Active {
+			return entity.APIKey{}, ErrUnauthorized
+		}
+		return c.key, nil
+	}
+
+	k, err := a.repo.GetAPIKeyByHash(ctx, hash)
+	if errors.Is(err, entity.ErrNotFound) {
+		return entity.APIKey{}, ErrUnauthorized
+	}
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+
+	a.mu.Lock()
+	a.cache[hash] = cachedKey{key: k, expires: time.Now().Add(apiKeyCacheTTL)}
+	a.mu.Unlock()
+
+	if !k.Active {
+		return entity.APIKey{}, ErrUnauthorized
+	}
+	return k, nil
+}
+
+// Allow counts a request against the key's rate limit and daily quota.
+func (a *APIKeyUseCase) Allow(ctx context.Context, k entity.APIKey) (RateStatus, error) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	a.mu.Lock()
+	if a.day != today {
+		// Pending counts of the previous day stay in pending until the next flush
+		a.day = today
+		a.used = make(map[uint]int64)
+		a.loaded = make(map[uint]bool)
+	}
+	needsLoad := k.DailyQuota > 0 && !a.loaded[k.ID]
+	a.mu.Unlock()
+
+	if needsLoad {
+		stored, err := a.repo.GetAPIKeyUsage(ctx, k.ID, now)
+		if err != nil {
+			return RateStatus{}, err
+		}
+		a.mu.Lock()
+		if !a.loaded[k.ID] {
+			a.used[k.ID] += stored
+			a.loaded[k.ID] = true
+		}
+		a.mu.Unlock()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	status := RateStatus{Limit: k.RateLimit}
+	if k.DailyQuota > 0 && a.used[k.ID] >= k.DailyQuota {
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		status.RetryAfter = midnight.Sub(now)
+		return status, ErrQuotaExceeded
+	}
+
+	if k.RateLimit > 0 {
+		b, ok := a.buckets[k.ID]
+		if !ok || b.capacity != float64(k.RateLimit) {
+			b = newTokenBucket(k.RateLimit, now)
+			a.buckets[k.ID] = b
+		}
+		allowed, wait := b.take(now)
+		status.Remaining = int(b.tokens)
+		if !allowed {
+			status.RetryAfter = wait
+			return status, ErrRateLimited
+		}
+	}
+
+	a.used[k.ID]++
+	a.pending[k.ID]++
+	return status, nil
+}
+
+// AllowUser counts a request of a signed-in user, made without an API key, against the
+// per-user rate limit.
+func (a *APIKeyUseCase) AllowUser(userID uint) (RateStatus, error) {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b, ok := a.users[userID]
+	if !ok {
+		b = newTokenBucket(userRateLimit, now)
+		a.users[userID] = b
+	}
+	allowed, wait := b.take(now)
+	status := RateStatus{Limit: userRateLimit, Remaining: int(b.tokens)}
+	if !allowed {
+		status.RetryAfter = wait
+		return status, ErrRateLimited
+	}
+	return status, nil
+}
+
+// Flush writes pending usage counters to the database.
+func (a *APIKeyUseCase) Flush(ctx context.Context) {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = make(map[uint]int64)
+	a.mu.Unlock()
+
+	day := time.Now()
+	for id, n := range pending {
+		if err := a.repo.AddAPIKeyUsage(ctx, id, day, n); err != nil {
+			slog.ErrorContext(ctx, "api key usage flush failed", "api_key_id", id, "err", err)
+			a.mu.Lock()
+			a.pending[id] += n
+			a.mu.Unlock()
+		}
+	}
+}
+
+// StartUsageFlusher periodically persists usage counters until ctx is cancelled.
+func (a *APIKeyUseCase) StartUsageFlusher(ctx context.Context) {
+	ticker := time.NewTicker(apiKeyFlushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			a.Flush(context.Background())
+			return
+		case <-ticker.C:
+			a.Flush(ctx)
+		}
+	}
+}
+
+// hashSecret returns the hex SHA-256 of a key or token, which is what gets stored.
+func hashSecret(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenBucket refills perMinute tokens evenly over a minute.
+type tokenBucket struct {
+	capacity float64
+	tokens   float64
+	rate     float64 // Tokens per second
+	last     time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     now,
+	}
+}
+
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
@@ -2,26 +2,56 @@
 package usecase
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
 	"time"
+
+	"github.com/ar-mokhtari/market-tracker/logger"
 )
 
 // StartAutomation starts the background worker for periodic price fetching.
 func (uc *PriceUseCase) StartAutomation() {
-	// Define a helper to avoid code duplication
-	runFetch := func() {
-		if err := uc.fetchFromExternal(); err != nil {
-			fmt.Printf("Fetch error: %v\n", err)
+	// Run immediately on startup; failed cycles are logged by FetchOnce
+	_ = uc.FetchOnce(context.Background())
+
+	timer := time.NewTimer(uc.FetchInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			_ = uc.FetchOnce(context.Background())
+		case <-uc.intervalChanged:
+			// Restart the wait with the reloaded interval
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			slog.Info("fetch interval changed", "interval", uc.FetchInterval())
 		}
+		timer.Reset(uc.FetchInterval())
 	}
+}
 
-	// Run immediately on startup
-	runFetch()
-
-	ticker := time.NewTicker(time.Duration(uc.FetchInterval) * time.Minute)
-	defer ticker.Stop()
+// ErrCircuitOpen is returned by FetchOnce while the provider circuit is open.
+var ErrCircuitOpen = errors.New("provider circuit is open")
 
-	for range ticker.C {
-		runFetch()
+// FetchOnce runs a single fetch cycle: it calls the provider, stores the prices and
+// runs OnUpdate. Every cycle gets its own ID so upstream calls, upserts and
+// broadcasts can be correlated.
+func (uc *PriceUseCase) FetchOnce(ctx context.Context) error {
+	ctx = logger.WithFetchID(ctx, logger.NewID())
+	if !uc.breaker.allow(time.Now()) {
+		slog.WarnContext(ctx, "fetch cycle skipped, provider circuit is open")
+		return ErrCircuitOpen
+	}
+	err := uc.fetchFromExternal(ctx)
+	uc.recordFetch(err)
+	if err != nil {
+		slog.ErrorContext(ctx, "fetch cycle failed", "err", err)
 	}
+	return err
 }
@@ -0,0 +1,25 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import "github.com/ar-mokhtari/market-tracker/entity"
+
+const (
+	gramsPerOunce = 31.1034768
+	ounceSymbol   = "XAUUSD"
+	dollarSymbol  = "USD"
+)
+
+// CoinBubble returns the premium, in percent, of a coin over the world value of its gold.
+// prices must hold the coin and the dollar in toman and the ounce in dollars.
+func CoinBubble(symbol string, prices map[string]float64) (float64, bool) {
+	grams, ok := entity.CoinGoldGrams(symbol)
+	if !ok {
+		return 0, false
+	}
+	coin, ounce, dollar := prices[symbol], prices[ounceSymbol], prices[dollarSymbol]
+	if coin <= 0 || ounce <= 0 || dollar <= 0 {
+		return 0, false
+	}
+	intrinsic := ounce * dollar / gramsPerOunce * grams
+	return (coin - intrinsic) / intrinsic * 100, true
+}
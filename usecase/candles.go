@@ -0,0 +1,219 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// tehranOffset aligns daily buckets to midnight in Tehran (UTC+03:30).
+const tehranOffset = 3*3600 + 1800
+
+const (
+	// MaxCandles caps the candles of one request; longer ranges need a larger interval.
+	MaxCandles = 5000
+	// maxInterval is the longest candle interval.
+	maxInterval = 366 * 24 * time.Hour
+)
+
+// ParseInterval accepts Go durations (15m, 4h) plus day and week suffixes (1d, 1w).
+func ParseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return time.Hour, nil
+	}
+
+	var d time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"), strings.HasSuffix(s, "w"):
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidInterval, s)
+		}
+		unit := 24 * time.Hour
+		if strings.HasSuffix(s, "w") {
+			unit *= 7
+		}
+		// Checked before multiplying, so large counts cannot overflow
+		if n > int(maxInterval/unit) {
+			return 0, fmt.Errorf("%w: must be at most 366d", ErrInvalidInterval)
+		}
+		d = time.Duration(n) * unit
+	default:
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidInterval, s)
+		}
+		d = parsed
+	}
+
+	if d < time.Minute {
+		return 0, fmt.Errorf("%w: must be at least 1m", ErrInvalidInterval)
+	}
+	if d > maxInterval {
+		return 0, fmt.Errorf("%w: must be at most 366d", ErrInvalidInterval)
+	}
+	return d, nil
+}
+
+// checkCandleRange returns ErrInvalidInterval when the range holds more than MaxCandles
+// candles of interval.
+func checkCandleRange(interval time.Duration, from, to time.Time) error {
+	if interval <= 0 || to.Sub(from)/interval >= MaxCandles {
+		return fmt.Errorf("%w: the range holds more than %d candles of %s, use a larger interval or a shorter range",
+			ErrInvalidInterval, MaxCandles, interval)
+	}
+	return nil
+}
+
+// GetCandles builds OHLC candles for a symbol from its recorded price changes, at most
+// MaxCandles of them. The last price before from opens the range, so it starts with flat
+// candles until the first change within it.
+func (uc *PriceUseCase) GetCandles(ctx context.Context, symbol string, interval time.Duration, from, to time.Time) ([]entity.Candle, error) {
+	if err := checkCandleRange(interval, from, to); err != nil {
+		return nil, err
+	}
+	const maxCandleRecords = 50000
+	history, err := uc.repo.GetHistoryRange(ctx, symbol, from, to, maxCandleRecords)
+	if err != nil {
+		return nil, err
+	}
+	b := newCandleBuilder(symbol, interval)
+	if err := uc.openCandles(ctx, b, symbol, from); err != nil {
+		return nil, err
+	}
+	return buildCandles(b, history, to), nil
+}
+
+// openCandles starts b at from with the last price of symbol before it, if there is one.
+func (uc *PriceUseCase) openCandles(ctx context.Context, b *candleBuilder, symbol string, from time.Time) error {
+	prior, err := uc.repo.PriceBefore(ctx, symbol, from)
+	if errors.Is(err, entity.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	b.open(prior, from)
+	return nil
+}
+
+// BuildCandles groups history rows into candles. History only stores changes,
+// so intervals without a record are filled with a flat candle at the last close.
+func BuildCandles(symbol string, history []entity.Price, interval time.Duration, until time.Time) []entity.Candle {
+	return buildCandles(newCandleBuilder(symbol, interval), history, until)
+}
+
+// buildCandles adds the history rows, in any order, to b and returns all its candles.
+func buildCandles(b *candleBuilder, history []entity.Price, until time.Time) []entity.Candle {
+	if b.step <= 0 {
+		return nil
+	}
+	rows := make([]entity.Price, len(history))
+	copy(rows, history)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].TimeUnix < rows[j].TimeUnix })
+
+	for _, p := range rows {
+		b.add(p)
+	}
+	return b.finish(until)
+}
+
+// candleBuilder builds the candles of a symbol from history rows added oldest first, so
+// long histories can be read and turned into candles a page at a time.
+type candleBuilder struct {
+	symbol  string
+	step    int64
+	candles []entity.Candle // Not yet taken; the last one may still grow
+	last    *entity.Candle  // The last candle built, kept after take to fill the next gap
+}
+
+func newCandleBuilder(symbol string, interval time.Duration) *candleBuilder {
+	return &candleBuilder{symbol: symbol, step: int64(interval / time.Second)}
+}
+
+func (b *candleBuilder) bucketOf(ts int64) int64 {
+	return floorDiv(ts+tehranOffset, b.step)*b.step - tehranOffset
+}
+
+// open starts the candles at the bucket of at with the price of p, a row recorded before
+// at. The candle counts no record of its own.
+func (b *candleBuilder) open(p entity.Price, at time.Time) {
+	v, err := p.Price.Float64()
+	if err != nil {
+		return
+	}
+	b.push(entity.Candle{Symbol: b.symbol, TimeUnix: b.bucketOf(at.Unix()), Open: v, High: v, Low: v, Close: v})
+}
+
+// add adds the next row. Rows without a numeric price are skipped.
+func (b *candleBuilder) add(p entity.Price) {
+	v, err := p.Price.Float64()
+	if err != nil {
+		return
+	}
+	bucket := b.bucketOf(p.TimeUnix)
+
+	if b.last != nil {
+		if b.last.TimeUnix == bucket {
+			b.last.High = max(b.last.High, v)
+			b.last.Low = min(b.last.Low, v)
+			b.last.Close = v
+			b.last.Count++
+			return
+		}
+		b.fill(bucket)
+	}
+	b.push(entity.Candle{Symbol: b.symbol, TimeUnix: bucket, Open: v, High: v, Low: v, Close: v, Count: 1})
+}
+
+// fill adds flat candles at the last close up to, but not including, bucket.
+func (b *candleBuilder) fill(bucket int64) {
+	for t := b.last.TimeUnix + b.step; t < bucket; t += b.step {
+		c := b.last.Close
+		b.push(entity.Candle{Symbol: b.symbol, TimeUnix: t, Open: c, High: c, Low: c, Close: c})
+	}
+}
+
+func (b *candleBuilder) push(c entity.Candle) {
+	b.candles = append(b.candles, c)
+	b.last = &b.candles[len(b.candles)-1]
+}
+
+// take returns the candles that later rows can no longer change: all but the last one.
+func (b *candleBuilder) take() []entity.Candle {
+	if len(b.candles) < 2 {
+		return nil
+	}
+	done := b.candles[:len(b.candles)-1]
+	b.candles = []entity.Candle{*b.last}
+	b.last = &b.candles[0]
+	return done
+}
+
+// finish fills the intervals after the last row up to until and returns the remaining candles.
+func (b *candleBuilder) finish(until time.Time) []entity.Candle {
+	if b.last != nil {
+		b.fill(b.bucketOf(until.Unix()) + b.step)
+	}
+	rest := b.candles
+	b.candles = nil
+	return rest
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// sparseRepo serves the history of one symbol; the other Repo methods are not used.
+type sparseRepo struct {
+	Repo
+	history []entity.Price // Oldest first
+}
+
+func (r sparseRepo) GetHistoryRange(_ context.Context, _ string, from, to time.Time, _ int) ([]entity.Price, error) {
+	var out []entity.Price
+	for _, p := range r.history {
+		if p.TimeUnix >= from.Unix() && p.TimeUnix <= to.Unix() {
+			out = append([]entity.Price{p}, out...)
+		}
+	}
+	return out, nil
+}
+
+func (r sparseRepo) PriceBefore(_ context.Context, _ string, at time.Time) (entity.Price, error) {
+	for i := len(r.history) - 1; i >= 0; i-- {
+		if r.history[i].TimeUnix < at.Unix() {
+			return r.history[i], nil
+		}
+	}
+	return entity.Price{}, entity.ErrNotFound
+}
+
+func TestGetCandlesSparseHistory(t *testing.T) {
+	day := 24 * time.Hour
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.FixedZone("Tehran", tehranOffset))
+	to := from.Add(4 * day)
+	price := func(at time.Time, v string) entity.Price {
+		return entity.Price{Symbol: "USD", Price: json.Number(v), TimeUnix: at.Unix()}
+	}
+	flat := func(d int, v float64) entity.Candle {
+		return entity.Candle{Symbol: "USD", TimeUnix: from.Add(time.Duration(d) * day).Unix(), Open: v, High: v, Low: v, Close: v}
+	}
+
+	tests := []struct {
+		name    string
+		history []entity.Price
+		want    []entity.Candle
+	}{
+		{
+			name:    "no change within the range",
+			history: []entity.Price{price(from.Add(-10*day), "100")},
+			want:    []entity.Candle{flat(0, 100), flat(1, 100), flat(2, 100), flat(3, 100), flat(4, 100)},
+		},
+		{
+			name:    "one change within the range",
+			history: []entity.Price{price(from.Add(-10*day), "100"), price(from.Add(2*day+time.Hour), "110")},
+			want: []entity.Candle{
+				flat(0, 100), flat(1, 100),
+				{Symbol: "USD", TimeUnix: from.Add(2 * day).Unix(), Open: 110, High: 110, Low: 110, Close: 110, Count: 1},
+				flat(3, 110), flat(4, 110),
+			},
+		},
+		{
+			// The earlier price opens the first candle
+			name:    "change in the first interval",
+			history: []entity.Price{price(from.Add(-10*day), "100"), price(from.Add(time.Hour), "90")},
+			want: []entity.Candle{
+				{Symbol: "USD", TimeUnix: from.Unix(), Open: 100, High: 100, Low: 90, Close: 90, Count: 1},
+				flat(1, 90), flat(2, 90), flat(3, 90), flat(4, 90),
+			},
+		},
+		{
+			name:    "no earlier price",
+			history: []entity.Price{price(from.Add(2*day+time.Hour), "110")},
+			want: []entity.Candle{
+				{Symbol: "USD", TimeUnix: from.Add(2 * day).Unix(), Open: 110, High: 110, Low: 110, Close: 110, Count: 1},
+				flat(3, 110), flat(4, 110),
+			},
+		},
+		{
+			name: "no history",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewPriceUseCase(sparseRepo{history: tt.history}, "", "", time.Minute)
+			got, err := uc.GetCandles(context.Background(), "USD", day, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d candles, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("candle %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
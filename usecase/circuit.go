@@ -0,0 +1,70 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit states reported by FetchStatus.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+const (
+	circuitFailureThreshold = 3
+	circuitCooldown         = 5 * time.Minute
+)
+
+// circuitBreaker stops calling the provider after consecutive failures and lets a
+// single trial call through once the cooldown has passed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	threshold int
+	cooldown  time.Duration
+	openedAt  time.Time
+	state     string
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: CircuitClosed}
+}
+
+// allow reports whether a call may be made now.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.state = CircuitHalfOpen
+	}
+	return b.state != CircuitOpen
+}
+
+// record updates the breaker with the result of a call.
+func (b *circuitBreaker) record(err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.state = CircuitClosed
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = now
+	}
+}
+
+// snapshot returns the state, the consecutive failures and when an open circuit will allow a trial call.
+func (b *circuitBreaker) snapshot() (state string, failures int, retryAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen {
+		retryAt = b.openedAt.Add(b.cooldown)
+	}
+	return b.state, b.failures, retryAt
+}
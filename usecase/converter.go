@@ -0,0 +1,179 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var (
+	ErrUnknownSymbol    = errors.New("unknown symbol")
+	ErrNoConversionPath = errors.New("no conversion path between symbols")
+)
+
+// ConversionStep is a single hop of a conversion path.
+type ConversionStep struct {
+	From   string
+	To     string
+	Symbol string  // The quote used for this hop
+	Rate   float64 // Multiplier applied to go From -> To
+}
+
+// Conversion is the outcome of converting an amount between two symbols.
+type Conversion struct {
+	From   string
+	To     string
+	Amount float64
+	Result float64
+	Rate   float64
+	Path   []string
+	Steps  []ConversionStep
+	At     time.Time // Zero when the latest rates were used
+}
+
+type rateEdge struct {
+	to     string
+	symbol string
+	rate   float64
+}
+
+// Convert converts amount of `from` into `to`, routing through cross rates when needed.
+// If at is zero the latest prices are used, otherwise the rates recorded at that time.
+func (uc *PriceUseCase) Convert(ctx context.Context, from, to string, amount float64, at time.Time) (*Conversion, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	var prices []entity.Price
+	var err error
+	if at.IsZero() {
+		prices, err = uc.latestPrices(ctx)
+	} else {
+		prices, err = uc.repo.GetPricesAt(ctx, at)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	graph := buildRateGraph(prices)
+	for _, s := range []string{from, to} {
+		if _, ok := graph[s]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSymbol, s)
+		}
+	}
+
+	steps, err := findPath(graph, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := &Conversion{From: from, To: to, Amount: amount, Rate: 1, Path: []string{from}, Steps: steps, At: at}
+	for _, st := range steps {
+		conv.Rate *= st.Rate
+		conv.Path = append(conv.Path, st.To)
+	}
+	conv.Result = amount * conv.Rate
+	return conv, nil
+}
+
+// buildRateGraph turns quotes into an undirected graph of exchange rates.
+func buildRateGraph(prices []entity.Price) map[string][]rateEdge {
+	graph := make(map[string][]rateEdge)
+	for _, p := range prices {
+		u, ok := entity.ParseUnit(p.Unit)
+		quote := string(u)
+		rate, err := p.Price.Float64()
+		if !ok || err != nil || rate <= 0 || p.Symbol == quote {
+			continue
+		}
+		graph[p.Symbol] = append(graph[p.Symbol], rateEdge{to: quote, symbol: p.Symbol, rate: rate})
+		graph[quote] = append(graph[quote], rateEdge{to: p.Symbol, symbol: p.Symbol, rate: 1 / rate})
+	}
+
+	// Rial is never quoted by the provider, it is a fixed multiple of toman
+	irt, irr := string(entity.UnitIRT), string(entity.UnitIRR)
+	if _, ok := graph[irt]; ok {
+		graph[irt] = append(graph[irt], rateEdge{to: irr, rate: entity.RialsPerToman})
+		graph[irr] = append(graph[irr], rateEdge{to: irt, rate: 1.0 / entity.RialsPerToman})
+	}
+	return graph
+}
+
+// findPath runs a breadth-first search so the shortest chain of rates is used.
+func findPath(graph map[string][]rateEdge, from, to string) ([]ConversionStep, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	prev := map[string]ConversionStep{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, e := range graph[node] {
+			if _, seen := prev[e.to]; seen {
+				continue
+			}
+			prev[e.to] = ConversionStep{From: node, To: e.to, Symbol: e.symbol, Rate: e.rate}
+			if e.to == to {
+				var steps []ConversionStep
+				for n := to; n != from; n = prev[n].From {
+					steps = append([]ConversionStep{prev[n]}, steps...)
+				}
+				return steps, nil
+			}
+			queue = append(queue, e.to)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s -> %s", ErrNoConversionPath, from, to)
+}
+
+// ConvertUnits re-quotes prices in the target unit using the latest rates.
+// Prices whose unit cannot be routed to the target are returned unchanged.
+func (uc *PriceUseCase) ConvertUnits(ctx context.Context, prices []entity.Price, target entity.Unit) ([]entity.Price, error) {
+	latest, err := uc.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph := buildRateGraph(latest)
+
+	rates := make(map[string]float64)
+	out := make([]entity.Price, 0, len(prices))
+	for _, p := range prices {
+		from := entity.NormalizeUnit(p.Unit)
+		rate, ok := rates[from]
+		if !ok {
+			rate = 0
+			if steps, err := findPath(graph, from, string(target)); err == nil {
+				rate = 1
+				for _, st := range steps {
+					rate *= st.Rate
+				}
+			}
+			rates[from] = rate
+		}
+		if rate == 0 {
+			out = append(out, p)
+			continue
+		}
+
+		p.Price = scaleNumber(p.Price, rate)
+		p.ChangeValue = scaleNumber(p.ChangeValue, rate)
+		p.Unit = string(target)
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func scaleNumber(n json.Number, rate float64) json.Number {
+	v, err := n.Float64()
+	if err != nil {
+		return n
+	}
+	return json.Number(strconv.FormatFloat(v*rate, 'f', -1, 64))
+}
@@ -0,0 +1,196 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/export"
+)
+
+// exportPageSize is the number of history rows read per query while streaming an export.
+const exportPageSize = 1000
+
+// ExportQuery selects the rows and columns of an export.
+type ExportQuery struct {
+	Symbols  []string // all known symbols when empty
+	From, To time.Time
+	Interval time.Duration // candles of this length, or the recorded history when zero
+	Options  export.Options
+}
+
+// Columns are the columns of the rows Export writes for q.
+func (q ExportQuery) Columns() []export.Column {
+	if q.Interval > 0 {
+		return export.CandleColumns(q.Options)
+	}
+	return export.HistoryColumns(q.Options)
+}
+
+// Export writes the rows selected by q to w, which must have been created with q.Columns().
+// w is flushed after every chunk of rows and then chunk, if set, is called; it lets the
+// caller push the data on and fail the export, for example when the client went away.
+// The caller closes w. Export returns the number of rows written.
+func (uc *PriceUseCase) Export(ctx context.Context, w export.Writer, q ExportQuery, chunk func() error) (int, error) {
+	symbols, err := uc.exportSymbols(ctx, q.Symbols)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	flush := func() error {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if chunk != nil {
+			return chunk()
+		}
+		return nil
+	}
+	if q.Interval > 0 {
+		err = uc.streamCandles(ctx, symbols, q.Interval, q.From, q.To, func(meta entity.Price, candles []entity.Candle) error {
+			for _, c := range candles {
+				if err := w.Write(export.CandleRow(meta, c, q.Options)); err != nil {
+					return err
+				}
+			}
+			rows += len(candles)
+			return flush()
+		})
+	} else {
+		err = uc.streamHistory(ctx, symbols, q.From, q.To, func(page []entity.Price) error {
+			for _, p := range page {
+				if err := w.Write(export.HistoryRow(p, q.Options)); err != nil {
+					return err
+				}
+			}
+			rows += len(page)
+			return flush()
+		})
+	}
+	return rows, err
+}
+
+// exportSymbols returns the latest price of every requested symbol sorted by symbol; it
+// carries the names, type and unit the history rows lack. All known symbols are returned
+// when none are given, and symbols without a latest price only carry their name.
+func (uc *PriceUseCase) exportSymbols(ctx context.Context, symbols []string) ([]entity.Price, error) {
+	all, err := uc.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]entity.Price, len(all))
+	for _, p := range all {
+		p.Unit = entity.NormalizeUnit(p.Unit)
+		latest[p.Symbol] = p
+	}
+
+	if len(symbols) == 0 {
+		for s := range latest {
+			symbols = append(symbols, s)
+		}
+	}
+	out := make([]entity.Price, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		p, ok := latest[s]
+		if !ok {
+			p = entity.Price{Symbol: s}
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+// streamHistory passes the recorded prices of the symbols within [from, to] to fn one page
+// at a time, symbol by symbol and oldest first, so only a page is held in memory. The
+// symbols come from exportSymbols and their names and unit are copied onto every row.
+func (uc *PriceUseCase) streamHistory(ctx context.Context, symbols []entity.Price, from, to time.Time, fn func([]entity.Price) error) error {
+	for _, meta := range symbols {
+		afterTime, afterID := from.Unix()-1, uint(0)
+		for {
+			page, err := uc.repo.HistoryPage(ctx, meta.Symbol, from, to, afterTime, afterID, exportPageSize)
+			if err != nil {
+				return err
+			}
+			if len(page) == 0 {
+				break
+			}
+			for i := range page {
+				page[i].NameEn, page[i].NameFa, page[i].Unit = meta.NameEn, meta.NameFa, meta.Unit
+			}
+			if err := fn(page); err != nil {
+				return err
+			}
+			last := page[len(page)-1]
+			afterTime, afterID = last.TimeUnix, last.ID
+			if len(page) < exportPageSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// streamCandles builds the candles of each symbol within [from, to] and passes them to fn
+// as its history pages are read, so only a page and its candles are held in memory. Like
+// GetCandles, the candles of a symbol start at from when it has an earlier price and at
+// its first record otherwise; every symbol is checked against MaxCandles before any
+// candle is passed on.
+func (uc *PriceUseCase) streamCandles(ctx context.Context, symbols []entity.Price, interval time.Duration, from, to time.Time, fn func(meta entity.Price, candles []entity.Candle) error) error {
+	builders := make([]*candleBuilder, len(symbols))
+	for i, meta := range symbols {
+		b := newCandleBuilder(meta.Symbol, interval)
+		if err := uc.openCandles(ctx, b, meta.Symbol, from); err != nil {
+			return err
+		}
+		builders[i] = b
+
+		start := from
+		if b.last == nil {
+			first, err := uc.repo.HistoryPage(ctx, meta.Symbol, from, to, from.Unix()-1, 0, 1)
+			if err != nil {
+				return err
+			}
+			if len(first) == 0 {
+				continue
+			}
+			start = time.Unix(first[0].TimeUnix, 0)
+		}
+		if err := checkCandleRange(interval, start, to); err != nil {
+			return fmt.Errorf("%s: %w", meta.Symbol, err)
+		}
+	}
+
+	for i, meta := range symbols {
+		b := builders[i]
+		err := uc.streamHistory(ctx, []entity.Price{meta}, from, to, func(page []entity.Price) error {
+			for _, p := range page {
+				b.add(p)
+			}
+			if candles := b.take(); len(candles) > 0 {
+				return fn(meta, candles)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if candles := b.finish(to); len(candles) > 0 {
+			if err := fn(meta, candles); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
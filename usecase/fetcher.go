@@ -2,45 +2,139 @@
 package usecase
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/logger"
+	"github.com/ar-mokhtari/market-tracker/metrics"
+	"github.com/ar-mokhtari/market-tracker/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (uc *PriceUseCase) fetchFromExternal() error {
+// providerName labels the metrics of the upstream price API.
+const providerName = "brsapi"
+
+func (uc *PriceUseCase) fetchFromExternal(ctx context.Context) (err error) {
+	start := time.Now()
+	var allUpdated []entity.Price
+	ctx, span := tracing.Tracer().Start(ctx, "fetch cycle", trace.WithAttributes(
+		attribute.String("provider", providerName), attribute.String("fetch.id", logger.FetchID(ctx))))
+	defer func() {
+		metrics.ObserveFetch(providerName, time.Since(start), len(allUpdated), err)
+		span.SetAttributes(attribute.Int("symbols.updated", len(allUpdated)))
+		tracing.End(span, err)
+	}()
+	slog.DebugContext(ctx, "fetch cycle started", "provider", providerName)
+
+	result, err := uc.requestPrices(ctx)
+	if err != nil {
+		return err
+	}
+	allUpdated = uc.storePrices(ctx, result)
+
+	if uc.OnUpdate != nil && len(allUpdated) > 0 {
+		uc.OnUpdate(ctx, allUpdated)
+	}
+	slog.InfoContext(ctx, "fetch cycle completed", "provider", providerName, "symbols", len(allUpdated), "elapsed_ms", time.Since(start).Milliseconds())
+	return nil
+}
+
+// requestPrices calls the provider and decodes its prices by category.
+func (uc *PriceUseCase) requestPrices(ctx context.Context) (map[string][]entity.Price, error) {
+	start := time.Now()
 	fullURL := fmt.Sprintf("%s?key=%s", uc.baseURL, uc.apiKey)
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 OPR/106.0.0.0")
 	req.Header.Set("Accept", "application/json, text/plain, */*")
 
-	resp, err := uc.httpClient.Do(req)
+	resp, err := uc.doUpstream(ctx, req)
 	if err != nil {
-		return fmt.Errorf("network level error: %w", err)
+		return nil, fmt.Errorf("network level error: %w", err)
 	}
 	defer resp.Body.Close()
+	slog.DebugContext(ctx, "upstream responded", "provider", providerName, "status", resp.StatusCode, "elapsed_ms", time.Since(start).Milliseconds())
 
-	var result map[string][]entity.Price
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	result, err := decodeProviderPrices(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return result, nil
+}
 
-	var allUpdated []entity.Price
+// storePrices upserts the prices of every category and returns them with their type and unit set.
+func (uc *PriceUseCase) storePrices(ctx context.Context, result map[string][]entity.Price) []entity.Price {
+	var updated []entity.Price
 	for category, prices := range result {
 		for _, p := range prices {
 			p.Type = category
-			_ = uc.repo.Upsert(p)
-			allUpdated = append(allUpdated, p)
+			p.Unit = entity.NormalizeUnit(p.Unit)
+			upsertStart := time.Now()
+			if err := uc.repo.Upsert(ctx, p); err != nil {
+				slog.WarnContext(ctx, "price upsert failed", "symbol", p.Symbol, "err", err)
+			}
+			metrics.ObserveUpsert(time.Since(upsertStart))
+			updated = append(updated, p)
 		}
 	}
 
-	if uc.OnUpdate != nil && len(allUpdated) > 0 {
-		uc.OnUpdate(allUpdated)
+	if len(updated) > 0 {
+		uc.indicatorCache.reset()
+		uc.refreshQuotes(ctx)
+		uc.observeFetched(updated)
 	}
-	return nil
+	return updated
+}
+
+// CheckProvider calls the provider without storing anything and returns the number of symbols it sent.
+func (uc *PriceUseCase) CheckProvider(ctx context.Context) (int, error) {
+	result, err := uc.requestPrices(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, prices := range result {
+		n += len(prices)
+	}
+	return n, nil
+}
+
+// doUpstream sends req inside a client span. The span and any error carry the URL
+// without its query string, which holds the provider key.
+func (uc *PriceUseCase) doUpstream(ctx context.Context, req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "GET "+req.URL.Host, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := uc.httpClient.Do(req.WithContext(ctx))
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// Keep the key out of logs and spans
+		urlErr.URL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	}
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	tracing.End(span, err)
+	return resp, err
 }
@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// upsertRepo stores upserted prices and serves them as the latest ones.
+type upsertRepo struct {
+	Repo
+	mu     sync.Mutex
+	prices []entity.Price
+}
+
+func (r *upsertRepo) Upsert(_ context.Context, p entity.Price) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prices = append(r.prices, p)
+	return nil
+}
+
+func (r *upsertRepo) ListLatest(context.Context) ([]entity.Price, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]entity.Price(nil), r.prices...), nil
+}
+
+func TestFetchCycleSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown, err := tracing.Setup(sdktrace.WithSyncer(exporter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantErr    bool
+		wantStatus codes.Code
+	}{
+		{"ok", http.StatusOK, `{"currency":[{"symbol":"USD","name_en":"US Dollar","price":105000,"unit":"تومان"}]}`, false, codes.Unset},
+		{"upstream error", http.StatusBadGateway, `bad gateway`, true, codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			var traceparent string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			uc := NewPriceUseCase(&upsertRepo{}, "secret-key", srv.URL, time.Minute)
+			if err := uc.fetchFromExternal(context.Background()); (err != nil) != tt.wantErr {
+				t.Fatalf("fetchFromExternal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want the upstream call and the fetch cycle", len(spans))
+			}
+			upstream, cycle := spans[0], spans[1]
+			if cycle.Name != "fetch cycle" || cycle.Parent.IsValid() {
+				t.Errorf("cycle span = %q with parent %v, want a root fetch cycle", cycle.Name, cycle.Parent.SpanID())
+			}
+			if want := "GET " + strings.TrimPrefix(srv.URL, "http://"); upstream.Name != want || upstream.SpanKind != trace.SpanKindClient {
+				t.Errorf("upstream span = %q (%v), want client span %q", upstream.Name, upstream.SpanKind, want)
+			}
+			if upstream.Parent.SpanID() != cycle.SpanContext.SpanID() {
+				t.Error("upstream span is not a child of the fetch cycle")
+			}
+			if upstream.Status.Code != tt.wantStatus || cycle.Status.Code != tt.wantStatus {
+				t.Errorf("statuses = %v, %v, want %v", upstream.Status.Code, cycle.Status.Code, tt.wantStatus)
+			}
+			// The provider continues the trace of the upstream span
+			if !strings.Contains(traceparent, upstream.SpanContext.SpanID().String()) {
+				t.Errorf("traceparent %q does not carry the upstream span", traceparent)
+			}
+			for _, s := range spans {
+				for _, a := range s.Attributes {
+					if strings.Contains(a.Value.Emit(), "secret-key") {
+						t.Errorf("span %q leaks the provider key in %s", s.Name, a.Key)
+					}
+				}
+			}
+		})
+	}
+}
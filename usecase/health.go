@@ -0,0 +1,144 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Component and overall health states.
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// ComponentHealth is the result of one readiness check.
+type ComponentHealth struct {
+	Status string
+	Error  string
+	Detail map[string]interface{}
+}
+
+// HealthCheck inspects one dependency. It should return promptly once ctx is done.
+type HealthCheck func(ctx context.Context) ComponentHealth
+
+// HealthReport aggregates the readiness checks; Status is down if any component is down.
+type HealthReport struct {
+	Status     string
+	Components map[string]ComponentHealth
+	CheckedAt  time.Time
+	Uptime     time.Duration
+}
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+type HealthUseCase struct {
+	startedAt time.Time
+	checks    []namedCheck
+}
+
+func NewHealthUseCase() *HealthUseCase {
+	return &HealthUseCase{startedAt: time.Now()}
+}
+
+// AddCheck registers a readiness check under a component name.
+func (h *HealthUseCase) AddCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// Live reports that the process is running; it checks no dependency.
+func (h *HealthUseCase) Live() HealthReport {
+	now := time.Now()
+	return HealthReport{Status: HealthUp, Components: map[string]ComponentHealth{}, CheckedAt: now, Uptime: now.Sub(h.startedAt)}
+}
+
+// Ready runs every check concurrently. A check that does not answer within
+// healthCheckTimeout is reported as down.
+func (h *HealthUseCase) Ready(ctx context.Context) HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := h.Live()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			result := runCheck(ctx, c.check)
+			mu.Lock()
+			report.Components[c.name] = result
+			if result.Status != HealthUp {
+				report.Status = HealthDown
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, check HealthCheck) ComponentHealth {
+	done := make(chan ComponentHealth, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+		return ComponentHealth{Status: HealthDown, Error: fmt.Sprintf("check timed out after %s", healthCheckTimeout)}
+	}
+}
+
+// FetcherCheck reports the fetcher down when no cycle succeeded within three fetch
+// intervals, but at least minAge, or since startup if none has yet.
+func (uc *PriceUseCase) FetcherCheck(minAge time.Duration) HealthCheck {
+	return func(context.Context) ComponentHealth {
+		maxAge := max(3*uc.FetchInterval(), minAge)
+		s := uc.FetchStatus()
+		last := s.LastSuccess
+		if last.IsZero() {
+			last = s.StartedAt
+		}
+		age := time.Since(last)
+
+		result := ComponentHealth{Status: HealthUp, Error: s.LastError, Detail: map[string]interface{}{
+			"max_age_seconds": int64(maxAge.Seconds()),
+		}}
+		if !s.LastSuccess.IsZero() {
+			result.Detail["last_success"] = s.LastSuccess.Unix()
+			result.Detail["age_seconds"] = int64(age.Seconds())
+		}
+		if age > maxAge {
+			result.Status = HealthDown
+			if result.Error == "" {
+				result.Error = fmt.Sprintf("no successful fetch for %s", age.Round(time.Second))
+			}
+		}
+		return result
+	}
+}
+
+// ProviderCheck reports the upstream provider down while its circuit is open.
+func (uc *PriceUseCase) ProviderCheck() HealthCheck {
+	return func(context.Context) ComponentHealth {
+		s := uc.FetchStatus()
+		result := ComponentHealth{Status: HealthUp, Detail: map[string]interface{}{
+			"name":                 providerName,
+			"circuit":              s.Circuit,
+			"consecutive_failures": s.Failures,
+		}}
+		if s.Circuit == CircuitOpen {
+			result.Status = HealthDown
+			result.Error = s.LastError
+			result.Detail["retry_at"] = s.RetryAt.Unix()
+		}
+		return result
+	}
+}
@@ -0,0 +1,496 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/calendar"
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/ar-mokhtari/market-tracker/export"
+	"github.com/ar-mokhtari/market-tracker/validation"
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// Import formats.
+const (
+	ImportBrsAPI = "brsapi" // Provider response, {"gold": [...], ...}, or an array of them
+	ImportCSV    = "csv"    // Spreadsheet with a header naming at least symbol, timestamp and price
+	ImportExport = "export" // CSV written by the export command
+)
+
+// ExportColumns is the header of history CSV exports without optional columns, which Import reads back.
+var ExportColumns = export.Header(export.HistoryColumns(export.Options{}))
+
+const (
+	importBatchSize = 500
+	maxImportErrors = 20
+	importMaxAhead  = 24 * time.Hour // Timestamps further in the future are rejected
+)
+
+// ImportOptions controls a bulk import.
+type ImportOptions struct {
+	Format   string // ImportBrsAPI, ImportCSV or ImportExport; detected from Name and the content when empty
+	Name     string // File name, used for format detection
+	Type     string // Type of rows without one whose symbol is not stored yet, e.g. gold or currency
+	DryRun   bool   // Validate and count without writing anything
+	Progress func(ImportProgress)
+}
+
+// ImportProgress counts the rows of an import so far.
+type ImportProgress struct {
+	Rows       int // Rows read
+	Imported   int // New history points, written unless it is a dry run
+	Duplicates int // Points already in the history or repeated in the input
+	Invalid    int // Rows rejected by validation
+}
+
+// ImportResult summarizes a finished import.
+type ImportResult struct {
+	ImportProgress
+	Format  string
+	Symbols int      // Symbols whose latest price was offered for update
+	Errors  []string // The first validation errors with their location
+}
+
+// Import loads historical prices into the history. Points already recorded for a
+// symbol at the same time are skipped, rows failing validation are counted and
+// reported, and the latest price of each symbol is replaced when the input has a
+// newer one. Missing types, units and names are taken from the stored symbols.
+func (uc *PriceUseCase) Import(ctx context.Context, r io.Reader, opts ImportOptions) (ImportResult, error) {
+	stored, err := uc.latestPrices(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	im := &importer{
+		uc:     uc,
+		opts:   opts,
+		known:  make(map[string]entity.Price, len(stored)),
+		seen:   make(map[string]map[int64]bool),
+		latest: make(map[string]entity.Price),
+	}
+	for _, p := range stored {
+		im.known[p.Symbol] = p
+	}
+
+	br := bufio.NewReader(r)
+	im.result.Format, err = detectImportFormat(br, opts)
+	if err != nil {
+		return im.result, err
+	}
+	emit := func(where string, p entity.Price, err error) error { return im.add(ctx, where, p, err) }
+	if im.result.Format == ImportBrsAPI {
+		err = readBrsAPI(br, emit)
+	} else {
+		err = readPriceCSV(br, emit)
+	}
+	if err != nil {
+		return im.result, err
+	}
+	return im.result, im.finish(ctx)
+}
+
+type importer struct {
+	uc     *PriceUseCase
+	opts   ImportOptions
+	known  map[string]entity.Price   // Stored latest price by symbol
+	seen   map[string]map[int64]bool // Recorded and imported times by symbol, loaded lazily
+	latest map[string]entity.Price   // Newest imported point by symbol
+	batch  []entity.Price
+	result ImportResult
+}
+
+func (im *importer) add(ctx context.Context, where string, p entity.Price, err error) error {
+	im.result.Rows++
+	if err == nil {
+		if err = im.complete(&p); err == nil {
+			err = validateImported(p)
+		}
+	}
+	if err != nil {
+		im.result.Invalid++
+		if len(im.result.Errors) < maxImportErrors {
+			im.result.Errors = append(im.result.Errors, fmt.Sprintf("%s: %v", where, err))
+		}
+		return nil
+	}
+	// A new symbol is stored in the unit of its first valid row, later rows are converted to it
+	if known := im.known[p.Symbol]; known.Unit == "" && p.Unit != "" {
+		known.Unit = p.Unit
+		im.known[p.Symbol] = known
+	}
+
+	times, ok := im.seen[p.Symbol]
+	if !ok {
+		recorded, err := im.uc.repo.HistoryTimes(ctx, p.Symbol)
+		if err != nil {
+			return err
+		}
+		times = make(map[int64]bool, len(recorded))
+		for _, t := range recorded {
+			times[t] = true
+		}
+		im.seen[p.Symbol] = times
+	}
+	if times[p.TimeUnix] {
+		im.result.Duplicates++
+		return nil
+	}
+	times[p.TimeUnix] = true
+
+	im.result.Imported++
+	if last, ok := im.latest[p.Symbol]; !ok || p.TimeUnix > last.TimeUnix {
+		im.latest[p.Symbol] = p
+	}
+	im.batch = append(im.batch, p)
+	if len(im.batch) >= importBatchSize {
+		return im.flush(ctx)
+	}
+	return nil
+}
+
+// complete normalizes a row and fills what it lacks from the stored symbol. Prices in
+// Rial or Toman are converted to the stored unit; other units differing from it are
+// rejected, as no rate is known at the row's time.
+func (im *importer) complete(p *entity.Price) error {
+	p.Symbol = strings.ToUpper(strings.TrimSpace(p.Symbol))
+	stored := im.known[p.Symbol]
+	if p.Type == "" {
+		p.Type = stored.Type
+	}
+	if p.Type == "" {
+		p.Type = im.opts.Type
+	}
+	if p.Unit == "" {
+		p.Unit = stored.Unit
+	}
+	p.Unit = entity.NormalizeUnit(p.Unit)
+	if p.NameEn == "" {
+		p.NameEn = stored.NameEn
+	}
+	if p.NameFa == "" {
+		p.NameFa = stored.NameFa
+	}
+
+	storedUnit := entity.NormalizeUnit(stored.Unit)
+	if storedUnit == "" || p.Unit == storedUnit {
+		return nil
+	}
+	irt, irr := string(entity.UnitIRT), string(entity.UnitIRR)
+	switch {
+	case p.Unit == irr && storedUnit == irt:
+		p.Price = scaleNumber(p.Price, 1.0/entity.RialsPerToman)
+	case p.Unit == irt && storedUnit == irr:
+		p.Price = scaleNumber(p.Price, entity.RialsPerToman)
+	default:
+		return fmt.Errorf("unit %s differs from the stored unit %s of %s", p.Unit, storedUnit, p.Symbol)
+	}
+	p.Unit = storedUnit
+	return nil
+}
+
+func validateImported(p entity.Price) error {
+	if err := validation.ValidatePrice(p); err != nil {
+		return err
+	}
+	if p.TimeUnix <= 0 {
+		return errors.New("timestamp is required")
+	}
+	if time.Unix(p.TimeUnix, 0).After(time.Now().Add(importMaxAhead)) {
+		return errors.New("timestamp is in the future")
+	}
+	if p.Type == "" {
+		return errors.New("type is unknown for a new symbol, add a type column or set the import type")
+	}
+	return nil
+}
+
+func (im *importer) flush(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !im.opts.DryRun && len(im.batch) > 0 {
+		if err := im.uc.repo.InsertHistory(ctx, im.batch); err != nil {
+			return err
+		}
+	}
+	im.batch = im.batch[:0]
+	if im.opts.Progress != nil {
+		im.opts.Progress(im.result.ImportProgress)
+	}
+	return nil
+}
+
+func (im *importer) finish(ctx context.Context) error {
+	if err := im.flush(ctx); err != nil {
+		return err
+	}
+	im.result.Symbols = len(im.latest)
+	if im.opts.DryRun {
+		return nil
+	}
+	for _, p := range im.latest {
+		t := time.Unix(p.TimeUnix, 0)
+		if p.Date == "" {
+			p.Date = calendar.FormatDate(t, calendar.Jalali)
+		}
+		if p.Time == "" {
+			p.Time = calendar.FormatTime(t)
+		}
+		if err := im.uc.repo.SetLatest(ctx, p); err != nil {
+			return err
+		}
+	}
+	if im.result.Imported > 0 {
+		im.uc.indicatorCache.reset()
+		im.uc.refreshQuotes(ctx)
+		im.uc.markUpdated()
+	}
+	return nil
+}
+
+// detectImportFormat uses the requested format, the file extension, or the first
+// byte and the CSV header, in that order.
+func detectImportFormat(br *bufio.Reader, opts ImportOptions) (string, error) {
+	switch opts.Format {
+	case ImportBrsAPI, ImportCSV, ImportExport:
+		return opts.Format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("%w: unknown format %q, use brsapi, csv or export", ErrInvalidImport, opts.Format)
+	}
+
+	head, _ := br.Peek(4096)
+	head = bytes.TrimPrefix(head, []byte("\ufeff"))
+	trimmed := bytes.TrimSpace(head)
+	if strings.EqualFold(filepath.Ext(opts.Name), ".json") || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		return ImportBrsAPI, nil
+	}
+	if len(trimmed) == 0 {
+		return "", fmt.Errorf("%w: the input is empty", ErrInvalidImport)
+	}
+	firstLine, _, _ := bytes.Cut(trimmed, []byte("\n"))
+	if strings.TrimSpace(string(firstLine)) == strings.Join(ExportColumns, ",") {
+		return ImportExport, nil
+	}
+	return ImportCSV, nil
+}
+
+// providerPrice is a price as the provider sends it; its name is the Persian name.
+type providerPrice struct {
+	entity.Price
+	Name string `json:"name"`
+}
+
+// decodeProviderPrices reads a provider response into prices by category.
+func decodeProviderPrices(r io.Reader) (map[string][]entity.Price, error) {
+	var raw map[string][]providerPrice
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return fromProvider(raw), nil
+}
+
+func fromProvider(raw map[string][]providerPrice) map[string][]entity.Price {
+	result := make(map[string][]entity.Price, len(raw))
+	for category, items := range raw {
+		prices := make([]entity.Price, len(items))
+		for i, item := range items {
+			prices[i] = item.Price
+			if prices[i].NameFa == "" {
+				prices[i].NameFa = item.Name
+			}
+		}
+		result[category] = prices
+	}
+	return result
+}
+
+// readBrsAPI reads one provider response or an array of them.
+func readBrsAPI(br *bufio.Reader, emit func(string, entity.Price, error) error) error {
+	var snapshots []map[string][]providerPrice
+	head, _ := br.Peek(4096)
+	if bytes.HasPrefix(bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\ufeff"))), []byte("[")) {
+		if err := json.NewDecoder(br).Decode(&snapshots); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+	} else {
+		var s map[string][]providerPrice
+		if err := json.NewDecoder(br).Decode(&s); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	for n, raw := range snapshots {
+		byCategory := fromProvider(raw)
+		for _, category := range sortedKeys(raw) {
+			for i, p := range byCategory[category] {
+				where := fmt.Sprintf("%s[%d]", category, i)
+				if len(snapshots) > 1 {
+					where = fmt.Sprintf("snapshot %d %s", n, where)
+				}
+				p.Type = category
+				var err error
+				if p.TimeUnix == 0 && p.Date != "" {
+					var t time.Time
+					if t, err = calendar.ParseDate(strings.TrimSpace(p.Date + " " + p.Time)); err == nil {
+						p.TimeUnix = t.Unix()
+					}
+				}
+				if err := emit(where, p, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string][]providerPrice) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// csvColumns maps accepted header names to fields.
+var csvColumns = map[string]string{
+	"symbol": "symbol", "نماد": "symbol",
+	"time_unix": "timestamp", "timestamp": "timestamp", "datetime": "timestamp",
+	"date": "date", "تاریخ": "date",
+	"time": "time", "ساعت": "time",
+	"price": "price", "close": "price", "قیمت": "price",
+	"type": "type", "unit": "unit", "name_en": "name_en", "name_fa": "name_fa", "name": "name_fa",
+}
+
+// readPriceCSV reads a CSV file with a header. Comma, semicolon and tab separators are accepted.
+func readPriceCSV(br *bufio.Reader, emit func(string, entity.Price, error) error) error {
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if head, _ := br.Peek(1024); !bytes.Contains(firstLine(head), []byte(",")) {
+		if bytes.Contains(firstLine(head), []byte(";")) {
+			cr.Comma = ';'
+		} else if bytes.Contains(firstLine(head), []byte("\t")) {
+			cr.Comma = '\t'
+		}
+	}
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("%w: cannot read the header: %v", ErrInvalidImport, err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if field, ok := csvColumns[h]; ok {
+			if _, dup := cols[field]; !dup {
+				cols[field] = i
+			}
+		}
+	}
+	_, hasTimestamp := cols["timestamp"]
+	_, hasDate := cols["date"]
+	_, hasTime := cols["time"]
+	if hasTime && !hasTimestamp && !hasDate {
+		// A lone time column holds the full timestamp
+		cols["timestamp"] = cols["time"]
+		hasTimestamp = true
+	}
+	for _, required := range []string{"symbol", "price"} {
+		if _, ok := cols[required]; !ok {
+			return fmt.Errorf("%w: the header has no %s column", ErrInvalidImport, required)
+		}
+	}
+	if !hasTimestamp && !hasDate {
+		return fmt.Errorf("%w: the header has no timestamp or date column", ErrInvalidImport)
+	}
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return err
+			}
+			if err := emit(fmt.Sprintf("line %d", parseErr.Line), entity.Price{}, parseErr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+		where := fmt.Sprintf("line %d", line)
+		get := func(field string) string {
+			if i, ok := cols[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		p := entity.Price{
+			Symbol: get("symbol"),
+			Price:  json.Number(cleanNumber(get("price"))),
+			Type:   strings.ToLower(get("type")),
+			Unit:   get("unit"),
+			NameEn: get("name_en"),
+			NameFa: get("name_fa"),
+		}
+		ts := get("timestamp")
+		if ts == "" {
+			ts = strings.TrimSpace(get("date") + " " + get("time"))
+		}
+		var rowErr error
+		if ts = latinDigits(ts); ts == "" {
+			rowErr = errors.New("timestamp is required")
+		} else if t, err := calendar.ParseTimestamp(ts); err != nil {
+			rowErr = err
+		} else {
+			p.TimeUnix = t.Unix()
+		}
+		if err := emit(where, p, rowErr); err != nil {
+			return err
+		}
+	}
+}
+
+func firstLine(b []byte) []byte {
+	line, _, _ := bytes.Cut(b, []byte("\n"))
+	return line
+}
+
+// cleanNumber removes thousands separators and converts Persian and Arabic digits.
+func cleanNumber(s string) string {
+	s = latinDigits(s)
+	s = strings.NewReplacer(",", "", "٬", "", "٫", ".", " ", "").Replace(s)
+	return s
+}
+
+// latinDigits converts Persian and Arabic-Indic digits to ASCII.
+func latinDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '۰' && r <= '۹':
+			return '0' + r - '۰'
+		case r >= '٠' && r <= '٩':
+			return '0' + r - '٠'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,290 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var ErrInvalidIndicator = errors.New("invalid indicator")
+
+const (
+	IndicatorSMA        = "sma"
+	IndicatorEMA        = "ema"
+	IndicatorRSI        = "rsi"
+	IndicatorBollinger  = "bollinger"
+	IndicatorVolatility = "volatility"
+)
+
+// bollingerWidth is the number of standard deviations between the middle and outer bands.
+const bollingerWidth = 2.0
+
+// maxIndicatorPeriod keeps the default warm-up range within MaxCandles.
+const maxIndicatorPeriod = MaxCandles / 10
+
+// IndicatorQuery selects an indicator computed over candle closes.
+type IndicatorQuery struct {
+	Symbol   string
+	Type     string
+	Period   int
+	Interval time.Duration
+	From     time.Time
+	To       time.Time
+}
+
+// IndicatorPoint is the indicator value at the close of one candle.
+// Upper and Lower are only set for Bollinger bands.
+type IndicatorPoint struct {
+	TimeUnix int64
+	Value    float64
+	Upper    *float64
+	Lower    *float64
+}
+
+// GetIndicator computes an indicator for a symbol, serving repeated queries from cache
+// until the next fetch cycle stores new prices.
+func (uc *PriceUseCase) GetIndicator(ctx context.Context, q IndicatorQuery) ([]IndicatorPoint, error) {
+	q.Type = strings.ToLower(q.Type)
+	if q.Period < 2 || q.Period > maxIndicatorPeriod {
+		return nil, fmt.Errorf("%w: period must be between 2 and %d", ErrInvalidIndicator, maxIndicatorPeriod)
+	}
+	if q.Interval < time.Minute {
+		return nil, fmt.Errorf("%w: must be at least 1m", ErrInvalidInterval)
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if q.From.IsZero() {
+		// Enough candles for the indicator to warm up before the first returned point
+		q.From = q.To.Add(-q.Interval * time.Duration(max(q.Period*5, 100)))
+	}
+
+	step := int64(q.Interval / time.Second)
+	key := fmt.Sprintf("%s|%s|%d|%d|%d|%d", strings.ToUpper(q.Symbol), q.Type, q.Period, step, q.From.Unix()/step, q.To.Unix()/step)
+	if cached, ok := uc.indicatorCache.get(key); ok {
+		return cached, nil
+	}
+
+	candles, err := uc.GetCandles(ctx, q.Symbol, q.Interval, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	var points []IndicatorPoint
+	switch q.Type {
+	case IndicatorSMA:
+		points = toPoints(candleTimes(candles), SMA(closes, q.Period))
+	case IndicatorEMA:
+		points = toPoints(candleTimes(candles), EMA(closes, q.Period))
+	case IndicatorRSI:
+		points = toPoints(candleTimes(candles), RSI(closes, q.Period))
+	case IndicatorVolatility:
+		points = toPoints(candleTimes(candles), Volatility(closes, q.Period))
+	case IndicatorBollinger:
+		middle, upper, lower := Bollinger(closes, q.Period, bollingerWidth)
+		points = toPoints(candleTimes(candles), middle)
+		for i := range points {
+			idx := len(closes) - len(points) + i
+			points[i].Upper, points[i].Lower = &upper[idx], &lower[idx]
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidIndicator, q.Type)
+	}
+
+	uc.indicatorCache.set(key, points, uc.FetchInterval())
+	return points, nil
+}
+
+// SMA returns the simple moving average. Values before the first full window are NaN.
+func SMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA returns the exponential moving average seeded with the SMA of the first window.
+func EMA(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if len(values) < period {
+		return out
+	}
+	k := 2.0 / float64(period+1)
+	var seed float64
+	for _, v := range values[:period] {
+		seed += v
+	}
+	out[period-1] = seed / float64(period)
+	for i := period; i < len(values); i++ {
+		out[i] = values[i]*k + out[i-1]*(1-k)
+	}
+	return out
+}
+
+// RSI returns the relative strength index using Wilder's smoothing.
+func RSI(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	if len(values) <= period {
+		return out
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		d := values[i] - values[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	out[period] = rsiValue(gain, loss)
+
+	for i := period + 1; i < len(values); i++ {
+		d := values[i] - values[i-1]
+		g, l := math.Max(d, 0), math.Max(-d, 0)
+		gain = (gain*float64(period-1) + g) / float64(period)
+		loss = (loss*float64(period-1) + l) / float64(period)
+		out[i] = rsiValue(gain, loss)
+	}
+	return out
+}
+
+func rsiValue(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// Bollinger returns the middle (SMA), upper and lower bands at width standard deviations.
+func Bollinger(values []float64, period int, width float64) (middle, upper, lower []float64) {
+	middle = SMA(values, period)
+	upper, lower = nanSlice(len(values)), nanSlice(len(values))
+	for i := period - 1; i < len(values); i++ {
+		sd := stdDev(values[i-period+1:i+1], middle[i])
+		upper[i] = middle[i] + width*sd
+		lower[i] = middle[i] - width*sd
+	}
+	return middle, upper, lower
+}
+
+// Volatility returns the rolling standard deviation of simple returns, in percent.
+func Volatility(values []float64, period int) []float64 {
+	out := nanSlice(len(values))
+	returns := make([]float64, len(values))
+	for i := 1; i < len(values); i++ {
+		if values[i-1] != 0 {
+			returns[i] = (values[i] - values[i-1]) / values[i-1]
+		}
+	}
+	for i := period; i < len(values); i++ {
+		window := returns[i-period+1 : i+1]
+		var mean float64
+		for _, r := range window {
+			mean += r
+		}
+		mean /= float64(period)
+		out[i] = stdDev(window, mean) * 100
+	}
+	return out
+}
+
+// stdDev is the population standard deviation around mean.
+func stdDev(values []float64, mean float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func nanSlice(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+func candleTimes(candles []entity.Candle) []int64 {
+	times := make([]int64, len(candles))
+	for i, c := range candles {
+		times[i] = c.TimeUnix
+	}
+	return times
+}
+
+// toPoints pairs values with candle times, dropping the warm-up values.
+func toPoints(times []int64, values []float64) []IndicatorPoint {
+	var points []IndicatorPoint
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		points = append(points, IndicatorPoint{TimeUnix: times[i], Value: v})
+	}
+	return points
+}
+
+// indicatorCache keeps computed indicators until they expire or new prices arrive.
+type indicatorCache struct {
+	mu      sync.Mutex
+	entries map[string]indicatorEntry
+}
+
+type indicatorEntry struct {
+	points  []IndicatorPoint
+	expires time.Time
+}
+
+func newIndicatorCache() *indicatorCache {
+	return &indicatorCache{entries: make(map[string]indicatorEntry)}
+}
+
+func (c *indicatorCache) get(key string) ([]IndicatorPoint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.points, true
+}
+
+func (c *indicatorCache) set(key string, points []IndicatorPoint, ttl time.Duration) {
+	c.mu.Lock()
+	c.entries[key] = indicatorEntry{points: points, expires: time.Now().Add(ttl)}
+	c.mu.Unlock()
+}
+
+// reset drops every entry, called when a fetch cycle stores new prices.
+func (c *indicatorCache) reset() {
+	c.mu.Lock()
+	c.entries = make(map[string]indicatorEntry)
+	c.mu.Unlock()
+}
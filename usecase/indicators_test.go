@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference series from the StockCharts ChartSchool spreadsheets on moving averages and
+// RSI, which publish their values rounded to two decimals. The RSI article text rounds
+// the first averages and differs slightly; the spreadsheet does not.
+var (
+	movingAverageCloses = []float64{
+		22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+		22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+		23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+	}
+	rsiCloses = []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+		46.21, 46.25, 45.71, 46.45, 45.78, 45.35, 44.03, 44.18, 44.22, 44.57,
+		43.42, 42.66, 43.13,
+	}
+)
+
+func TestIndicators(t *testing.T) {
+	tests := []struct {
+		name   string
+		got    []float64
+		warmup int // Leading values that are NaN
+		want   []float64
+	}{
+		{
+			name:   "sma 10",
+			got:    SMA(movingAverageCloses, 10),
+			warmup: 9,
+			want: []float64{
+				22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08,
+				23.21, 23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28, 23.13,
+			},
+		},
+		{
+			name:   "ema 10",
+			got:    EMA(movingAverageCloses, 10),
+			warmup: 9,
+			want: []float64{
+				22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28,
+				23.34, 23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+			},
+		},
+		{
+			name:   "rsi 14",
+			got:    RSI(rsiCloses, 14),
+			warmup: 14,
+			want: []float64{
+				70.46, 66.25, 66.48, 69.35, 66.29, 57.92, 62.88, 63.21, 56.01, 62.34,
+				54.67, 50.39, 40.02, 41.49, 41.90, 45.50, 37.32, 33.09, 37.79,
+			},
+		},
+		{
+			// The population standard deviation of 2, 4, 4, 4, 5, 5, 7, 9 is 2 around a mean of 5
+			name:   "bollinger middle",
+			got:    bollingerBand(0),
+			warmup: 7,
+			want:   []float64{5},
+		},
+		{
+			name:   "bollinger upper",
+			got:    bollingerBand(1),
+			warmup: 7,
+			want:   []float64{9},
+		},
+		{
+			name:   "bollinger lower",
+			got:    bollingerBand(2),
+			warmup: 7,
+			want:   []float64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.got) != tt.warmup+len(tt.want) {
+				t.Fatalf("got %d values, want %d", len(tt.got), tt.warmup+len(tt.want))
+			}
+			for i, v := range tt.got[:tt.warmup] {
+				if !math.IsNaN(v) {
+					t.Errorf("value %d = %.4f during warm-up, want NaN", i, v)
+				}
+			}
+			for i, want := range tt.want {
+				if got := tt.got[tt.warmup+i]; math.Abs(got-want) > 0.01 {
+					t.Errorf("value %d = %.4f, want %.2f", tt.warmup+i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// bollingerBand returns the middle (0), upper (1) or lower (2) band of a series with a
+// known standard deviation.
+func bollingerBand(band int) []float64 {
+	middle, upper, lower := Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+	return [][]float64{middle, upper, lower}[band]
+}
@@ -0,0 +1,261 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Page sizes of list queries.
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
+
+// PriceQuery filters, sorts and pages the latest prices.
+type PriceQuery struct {
+	Types   []string
+	Symbols []string
+	Search  string // part of the symbol or of the English or Persian name
+	Sort    string // a sortable field, prefixed with - for descending order; symbol when empty
+	Limit   int    // DefaultPageSize when zero
+	Cursor  string // NextCursor of the previous page
+}
+
+// PricePage is one page of prices; NextCursor is empty on the last page.
+type PricePage struct {
+	Prices     []entity.Price
+	NextCursor string
+}
+
+// QueryPrices returns a page of the latest prices. A cursor only continues the sort
+// order it was issued for.
+func (uc *PriceUseCase) QueryPrices(ctx context.Context, q PriceQuery) (PricePage, error) {
+	f := entity.PriceFilter{Search: strings.TrimSpace(q.Search)}
+	for _, t := range q.Types {
+		f.Types = append(f.Types, strings.ToLower(t))
+	}
+	for _, s := range q.Symbols {
+		f.Symbols = append(f.Symbols, strings.ToUpper(s))
+	}
+
+	f.Sort, f.Desc = strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+	switch f.Sort {
+	case "":
+		f.Sort = entity.SortSymbol
+	case entity.SortSymbol, entity.SortPrice, entity.SortChangePercent, entity.SortUpdatedAt:
+	default:
+		return PricePage{}, fmt.Errorf("%w: sort must be symbol, price, change_percent or updated_at", ErrInvalidQuery)
+	}
+
+	limit, err := pageSize(q.Limit)
+	if err != nil {
+		return PricePage{}, err
+	}
+	if q.Cursor != "" {
+		var after entity.PriceCursor
+		if err := DecodeCursor(q.Cursor, &after); err != nil {
+			return PricePage{}, err
+		}
+		if after.Sort != f.Sort || after.Desc != f.Desc {
+			return PricePage{}, fmt.Errorf("%w: the cursor belongs to another sort order", ErrInvalidQuery)
+		}
+		f.After = &after
+	}
+
+	all, err := uc.latestPrices(ctx)
+	if err != nil {
+		return PricePage{}, err
+	}
+	prices, err := filterPrices(all, f)
+	if err != nil {
+		return PricePage{}, err
+	}
+	page := PricePage{Prices: prices}
+	if len(prices) > limit {
+		page.Prices = prices[:limit]
+		last := page.Prices[limit-1]
+		page.NextCursor = EncodeCursor(entity.PriceCursor{Sort: f.Sort, Desc: f.Desc, Value: sortValue(last, f.Sort), ID: last.ID})
+	}
+	return page, nil
+}
+
+// HistoryQuery pages the recorded prices of a symbol within [From, To], oldest first.
+type HistoryQuery struct {
+	Symbol   string
+	From, To time.Time
+	Limit    int    // DefaultPageSize when zero
+	Cursor   string // NextCursor of the previous page
+}
+
+// historyCursor is the position of a row in the history of a symbol.
+type historyCursor struct {
+	At int64 `json:"t"`
+	ID uint  `json:"id"`
+}
+
+// QueryHistory returns a page of the history of a symbol. The rows carry the type, unit
+// and names of the symbol's latest price; symbols without one are unknown.
+func (uc *PriceUseCase) QueryHistory(ctx context.Context, q HistoryQuery) (PricePage, error) {
+	limit, err := pageSize(q.Limit)
+	if err != nil {
+		return PricePage{}, err
+	}
+	quotes, err := uc.GetQuotes(ctx, []string{q.Symbol})
+	if err != nil {
+		return PricePage{}, err
+	}
+	if len(quotes) == 0 {
+		return PricePage{}, fmt.Errorf("%w: %s", ErrUnknownSymbol, q.Symbol)
+	}
+	meta := quotes[0]
+
+	after := historyCursor{At: q.From.Unix() - 1}
+	if q.Cursor != "" {
+		if err := DecodeCursor(q.Cursor, &after); err != nil {
+			return PricePage{}, err
+		}
+	}
+	// One extra row tells whether another page follows
+	prices, err := uc.repo.HistoryPage(ctx, meta.Symbol, q.From, q.To, after.At, after.ID, limit+1)
+	if err != nil {
+		return PricePage{}, err
+	}
+	for i := range prices {
+		prices[i].NameEn, prices[i].NameFa, prices[i].Unit = meta.NameEn, meta.NameFa, meta.Unit
+	}
+	page := PricePage{Prices: prices}
+	if len(prices) > limit {
+		page.Prices = prices[:limit]
+		last := page.Prices[limit-1]
+		page.NextCursor = EncodeCursor(historyCursor{At: last.TimeUnix, ID: last.ID})
+	}
+	return page, nil
+}
+
+// filterPrices returns the prices matching f in its order, ties broken by id, starting
+// after f.After. Search matches case insensitively, like the database collation.
+func filterPrices(all []entity.Price, f entity.PriceFilter) ([]entity.Price, error) {
+	var after priceSortKey
+	if f.After != nil {
+		var err error
+		if after, err = parseSortKey(f.After.Value, f.Sort); err != nil {
+			return nil, err
+		}
+	}
+	compare := func(a priceSortKey, aID uint, b priceSortKey, bID uint) int {
+		c := a.compare(b)
+		if c == 0 {
+			c = cmp.Compare(aID, bID)
+		}
+		if f.Desc {
+			c = -c
+		}
+		return c
+	}
+
+	search := strings.ToLower(f.Search)
+	var prices []entity.Price
+	for _, p := range all {
+		switch {
+		case len(f.Types) > 0 && !slices.Contains(f.Types, p.Type),
+			len(f.Symbols) > 0 && !slices.Contains(f.Symbols, p.Symbol),
+			search != "" && !strings.Contains(strings.ToLower(p.Symbol), search) &&
+				!strings.Contains(strings.ToLower(p.NameEn), search) && !strings.Contains(strings.ToLower(p.NameFa), search),
+			f.After != nil && compare(sortKey(p, f.Sort), p.ID, after, f.After.ID) <= 0:
+			continue
+		}
+		prices = append(prices, p)
+	}
+	slices.SortFunc(prices, func(a, b entity.Price) int {
+		return compare(sortKey(a, f.Sort), a.ID, sortKey(b, f.Sort), b.ID)
+	})
+	return prices, nil
+}
+
+// priceSortKey is the value of a price in a sort field: text for symbols, a number otherwise.
+type priceSortKey struct {
+	num float64
+	str string
+}
+
+func (k priceSortKey) compare(o priceSortKey) int {
+	if c := cmp.Compare(k.num, o.num); c != 0 {
+		return c
+	}
+	return strings.Compare(k.str, o.str)
+}
+
+func sortKey(p entity.Price, field string) priceSortKey {
+	key, _ := parseSortKey(sortValue(p, field), field)
+	return key
+}
+
+// parseSortKey reads a value made by sortValue; prices that are not numbers sort as zero.
+func parseSortKey(value, field string) (priceSortKey, error) {
+	if field == entity.SortSymbol {
+		return priceSortKey{str: value}, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil && field != entity.SortPrice {
+		return priceSortKey{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return priceSortKey{num: n}, nil
+}
+
+// sortValue is the value of a price in the given sort field, as cursors carry it.
+func sortValue(p entity.Price, field string) string {
+	switch field {
+	case entity.SortPrice:
+		return p.Price.String()
+	case entity.SortChangePercent:
+		return strconv.FormatFloat(p.ChangePercent, 'f', -1, 64)
+	case entity.SortUpdatedAt:
+		if p.UpdatedAt.IsZero() {
+			return "0"
+		}
+		return strconv.FormatInt(p.UpdatedAt.Unix(), 10)
+	}
+	return p.Symbol
+}
+
+// pageSize applies the default and the bounds of list page sizes.
+func pageSize(limit int) (int, error) {
+	switch {
+	case limit == 0:
+		return DefaultPageSize, nil
+	case limit < 0 || limit > MaxPageSize:
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	return limit, nil
+}
+
+// EncodeCursor turns a position in a list into an opaque, URL safe cursor.
+func EncodeCursor(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor into v.
+func DecodeCursor(cursor string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return nil
+}
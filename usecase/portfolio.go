@@ -0,0 +1,526 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var (
+	ErrInvalidPortfolio   = errors.New("invalid portfolio")
+	ErrInvalidTransaction = errors.New("invalid transaction")
+)
+
+const (
+	maxPortfolioPoints = 366
+	quantityEpsilon    = 1e-9
+)
+
+// HoldingValue is the position in one symbol, valued at the latest prices.
+// Costs use the average cost method; Priced is false when the symbol has no price to value it with.
+type HoldingValue struct {
+	Symbol     string
+	Type       string
+	Quantity   float64
+	AvgCostIRT float64
+	CostIRT    float64
+	CostUSD    float64
+	PriceIRT   float64
+	ValueIRT   float64
+	ValueUSD   float64
+	PnLIRT     float64
+	PnLUSD     float64
+	PnLPercent float64
+	Priced     bool
+}
+
+// AllocationSlice is the share of the portfolio value held in one price type.
+type AllocationSlice struct {
+	Type     string
+	ValueIRT float64
+	Percent  float64
+}
+
+// PortfolioValuation values a portfolio in toman and USD.
+type PortfolioValuation struct {
+	Portfolio     entity.Portfolio
+	ValueIRT      float64
+	ValueUSD      float64
+	CostIRT       float64
+	CostUSD       float64
+	UnrealizedIRT float64
+	UnrealizedUSD float64
+	RealizedIRT   float64
+	RealizedUSD   float64
+	PnLPercent    float64 // Unrealized P&L relative to the cost of the current holdings
+	Holdings      []HoldingValue
+	Allocation    []AllocationSlice
+	AsOf          time.Time
+}
+
+// PortfolioPoint is the value of a portfolio at one time, using the prices recorded then.
+type PortfolioPoint struct {
+	TimeUnix int64
+	ValueIRT float64
+	ValueUSD float64
+	CostIRT  float64
+}
+
+type PortfolioUseCase struct {
+	repo   PortfolioRepo
+	prices Repo
+	quotes *PriceUseCase // the cached latest prices
+}
+
+func NewPortfolioUseCase(repo PortfolioRepo, prices Repo, quotes *PriceUseCase) *PortfolioUseCase {
+	return &PortfolioUseCase{repo: repo, prices: prices, quotes: quotes}
+}
+
+func (p *PortfolioUseCase) Create(ctx context.Context, pf *entity.Portfolio) error {
+	pf.Name = strings.TrimSpace(pf.Name)
+	if pf.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPortfolio)
+	}
+	if err := p.repo.CreatePortfolio(ctx, pf); err != nil {
+		if errors.Is(err, entity.ErrAlreadyExists) {
+			return fmt.Errorf("%w: a portfolio named %q", entity.ErrAlreadyExists, pf.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns a portfolio of the user. Other users' portfolios are reported as not found.
+func (p *PortfolioUseCase) Get(ctx context.Context, id, userID uint) (entity.Portfolio, error) {
+	pf, err := p.repo.GetPortfolio(ctx, id)
+	if err == nil && pf.UserID != userID {
+		return entity.Portfolio{}, entity.ErrNotFound
+	}
+	return pf, err
+}
+
+func (p *PortfolioUseCase) List(ctx context.Context, userID uint) ([]entity.Portfolio, error) {
+	return p.repo.ListPortfolios(ctx, userID)
+}
+
+func (p *PortfolioUseCase) Delete(ctx context.Context, id, userID uint) error {
+	if _, err := p.Get(ctx, id, userID); err != nil {
+		return err
+	}
+	return p.repo.DeletePortfolio(ctx, id)
+}
+
+func (p *PortfolioUseCase) Transactions(ctx context.Context, id, userID uint) ([]entity.PortfolioTransaction, error) {
+	if _, err := p.Get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return p.repo.ListTransactions(ctx, id)
+}
+
+// AddTransaction records a buy or sell. The price is converted to toman and USD at the rates
+// recorded when it was executed, and a sell may not exceed the quantity held at that time.
+func (p *PortfolioUseCase) AddTransaction(ctx context.Context, userID uint, t *entity.PortfolioTransaction) error {
+	if _, err := p.Get(ctx, t.PortfolioID, userID); err != nil {
+		return err
+	}
+
+	t.Symbol = strings.ToUpper(strings.TrimSpace(t.Symbol))
+	t.Side = strings.ToLower(strings.TrimSpace(t.Side))
+	switch {
+	case t.Symbol == "":
+		return fmt.Errorf("%w: symbol is required", ErrInvalidTransaction)
+	case t.Side != entity.TransactionBuy && t.Side != entity.TransactionSell:
+		return fmt.Errorf("%w: side must be buy or sell", ErrInvalidTransaction)
+	case t.Quantity <= 0:
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidTransaction)
+	case t.Price < 0:
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidTransaction)
+	}
+	unit := entity.UnitIRT
+	if t.Unit != "" {
+		u, ok := entity.ParseUnit(t.Unit)
+		if !ok {
+			return fmt.Errorf("%w: unknown unit %q", ErrInvalidTransaction, t.Unit)
+		}
+		unit = u
+	}
+	t.Unit = string(unit)
+	// Only symbols with prices can be valued
+	known, err := p.quotes.GetQuotes(ctx, []string{t.Symbol})
+	if err != nil {
+		return err
+	}
+	if len(known) == 0 {
+		return fmt.Errorf("%w: unknown symbol %s", ErrInvalidTransaction, t.Symbol)
+	}
+	if t.ExecutedAt.IsZero() {
+		t.ExecutedAt = time.Now()
+	}
+	if t.ExecutedAt.After(time.Now().Add(time.Minute)) {
+		return fmt.Errorf("%w: executed_at is in the future", ErrInvalidTransaction)
+	}
+
+	// Price history only starts with our own data; older transactions fall back to the latest rates
+	irt, usd, err := p.transactionRates(ctx, t.Unit, t.ExecutedAt)
+	if err != nil {
+		return err
+	}
+	t.PriceIRT = t.Price * irt
+	t.PriceUSD = t.Price * usd
+
+	txs, err := p.repo.ListTransactions(ctx, t.PortfolioID)
+	if err != nil {
+		return err
+	}
+	if err := checkHoldings(append(txs, *t)); err != nil {
+		return err
+	}
+	return p.repo.CreateTransaction(ctx, t)
+}
+
+// transactionRates returns the toman and USD value of one unit at the given time.
+func (p *PortfolioUseCase) transactionRates(ctx context.Context, unit string, at time.Time) (float64, float64, error) {
+	past, err := p.prices.GetPricesAt(ctx, at)
+	if err != nil {
+		return 0, 0, err
+	}
+	graph := buildRateGraph(past)
+	irt, okIRT := pathRate(graph, unit, string(entity.UnitIRT))
+	usd, okUSD := pathRate(graph, unit, string(entity.UnitUSD))
+	if okIRT && okUSD {
+		return irt, usd, nil
+	}
+
+	latest, err := p.quotes.latestPrices(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	graph = buildRateGraph(latest)
+	irt, okIRT = pathRate(graph, unit, string(entity.UnitIRT))
+	usd, okUSD = pathRate(graph, unit, string(entity.UnitUSD))
+	if !okIRT || !okUSD {
+		return 0, 0, fmt.Errorf("%w: no rate to value %s in IRT and USD", ErrInvalidTransaction, unit)
+	}
+	return irt, usd, nil
+}
+
+// DeleteTransaction removes a transaction unless later sells depend on it.
+func (p *PortfolioUseCase) DeleteTransaction(ctx context.Context, portfolioID, id, userID uint) error {
+	txs, err := p.Transactions(ctx, portfolioID, userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(txs, func(t entity.PortfolioTransaction) bool { return t.ID == id })
+	if i < 0 {
+		return entity.ErrNotFound
+	}
+	if err := checkHoldings(slices.Delete(txs, i, i+1)); err != nil {
+		return err
+	}
+	return p.repo.DeleteTransaction(ctx, portfolioID, id)
+}
+
+// Valuation values a portfolio at the latest prices.
+func (p *PortfolioUseCase) Valuation(ctx context.Context, id, userID uint) (PortfolioValuation, error) {
+	pf, err := p.Get(ctx, id, userID)
+	if err != nil {
+		return PortfolioValuation{}, err
+	}
+	latest, err := p.quotes.latestPrices(ctx)
+	if err != nil {
+		return PortfolioValuation{}, err
+	}
+	return p.valuate(ctx, pf, latest)
+}
+
+// UserValuations values the portfolios of several users at the latest prices,
+// for real-time updates after a fetch cycle. Users without portfolios are left out.
+func (p *PortfolioUseCase) UserValuations(ctx context.Context, userIDs []uint) (map[uint][]PortfolioValuation, error) {
+	latest, err := p.quotes.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]PortfolioValuation)
+	for _, uid := range userIDs {
+		portfolios, err := p.repo.ListPortfolios(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, pf := range portfolios {
+			v, err := p.valuate(ctx, pf, latest)
+			if err != nil {
+				return nil, err
+			}
+			result[uid] = append(result[uid], v)
+		}
+	}
+	return result, nil
+}
+
+func (p *PortfolioUseCase) valuate(ctx context.Context, pf entity.Portfolio, latest []entity.Price) (PortfolioValuation, error) {
+	txs, err := p.repo.ListTransactions(ctx, pf.ID)
+	if err != nil {
+		return PortfolioValuation{}, err
+	}
+	positions, realizedIRT, realizedUSD := buildPositions(txs, time.Time{})
+
+	types := make(map[string]string, len(latest))
+	for _, q := range latest {
+		types[q.Symbol] = q.Type
+	}
+	graph := buildRateGraph(latest)
+
+	v := PortfolioValuation{Portfolio: pf, RealizedIRT: realizedIRT, RealizedUSD: realizedUSD, AsOf: time.Now(), Holdings: []HoldingValue{}}
+	byType := make(map[string]float64)
+	for _, pos := range positions {
+		h := HoldingValue{
+			Symbol:     pos.symbol,
+			Type:       types[pos.symbol],
+			Quantity:   pos.quantity,
+			AvgCostIRT: pos.costIRT / pos.quantity,
+			CostIRT:    pos.costIRT,
+			CostUSD:    pos.costUSD,
+		}
+		priceIRT, okIRT := pathRate(graph, pos.symbol, string(entity.UnitIRT))
+		priceUSD, okUSD := pathRate(graph, pos.symbol, string(entity.UnitUSD))
+		if okIRT && okUSD {
+			h.Priced = true
+			h.PriceIRT = priceIRT
+			h.ValueIRT = pos.quantity * priceIRT
+			h.ValueUSD = pos.quantity * priceUSD
+			h.PnLIRT = h.ValueIRT - h.CostIRT
+			h.PnLUSD = h.ValueUSD - h.CostUSD
+			h.PnLPercent = percentOf(h.PnLIRT, h.CostIRT)
+
+			v.ValueIRT += h.ValueIRT
+			v.ValueUSD += h.ValueUSD
+			v.UnrealizedIRT += h.PnLIRT
+			v.UnrealizedUSD += h.PnLUSD
+			byType[h.Type] += h.ValueIRT
+		}
+		v.CostIRT += h.CostIRT
+		v.CostUSD += h.CostUSD
+		v.Holdings = append(v.Holdings, h)
+	}
+	v.PnLPercent = percentOf(v.UnrealizedIRT, v.CostIRT)
+
+	v.Allocation = make([]AllocationSlice, 0, len(byType))
+	for t, value := range byType {
+		v.Allocation = append(v.Allocation, AllocationSlice{Type: t, ValueIRT: value, Percent: percentOf(value, v.ValueIRT)})
+	}
+	sort.Slice(v.Allocation, func(i, j int) bool { return v.Allocation[i].ValueIRT > v.Allocation[j].ValueIRT })
+	return v, nil
+}
+
+// History values a portfolio every interval between from and to, using the prices
+// recorded in price_history at each point. A zero from starts at the first transaction.
+// Each point reads the last price of the held symbols and of the symbols their conversion
+// goes through, an indexed lookup each, rather than a snapshot of every symbol.
+func (p *PortfolioUseCase) History(ctx context.Context, id, userID uint, from, to time.Time, interval time.Duration) ([]PortfolioPoint, error) {
+	if _, err := p.Get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	txs, err := p.repo.ListTransactions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) == 0 {
+		return []PortfolioPoint{}, nil
+	}
+	if from.IsZero() {
+		from = txs[0].ExecutedAt
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if !to.After(from) {
+		return []PortfolioPoint{}, nil
+	}
+	if to.Sub(from)/interval >= maxPortfolioPoints {
+		return nil, fmt.Errorf("%w: at most %d points, use a longer interval", ErrInvalidInterval, maxPortfolioPoints)
+	}
+
+	latest, err := p.quotes.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	needed := valuationSymbols(buildRateGraph(latest), txs)
+
+	points := make([]PortfolioPoint, 0, to.Sub(from)/interval+1)
+	for t := from; !t.After(to); t = t.Add(interval) {
+		positions, _, _ := buildPositions(txs, t)
+		point := PortfolioPoint{TimeUnix: t.Unix()}
+		if len(positions) > 0 {
+			prices, err := p.pricesAt(ctx, positions, needed, t)
+			if err != nil {
+				return nil, err
+			}
+			graph := buildRateGraph(prices)
+			for _, pos := range positions {
+				point.CostIRT += pos.costIRT
+				if rate, ok := pathRate(graph, pos.symbol, string(entity.UnitIRT)); ok {
+					point.ValueIRT += pos.quantity * rate
+				}
+				if rate, ok := pathRate(graph, pos.symbol, string(entity.UnitUSD)); ok {
+					point.ValueUSD += pos.quantity * rate
+				}
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// valuationSymbols returns, for every symbol traded in txs, the symbols whose prices value it
+// in IRT and USD: the symbol itself and the rates on its conversion paths in graph.
+func valuationSymbols(graph map[string][]rateEdge, txs []entity.PortfolioTransaction) map[string][]string {
+	needed := make(map[string][]string)
+	for _, t := range txs {
+		if _, ok := needed[t.Symbol]; ok {
+			continue
+		}
+		symbols := []string{t.Symbol}
+		for _, unit := range []entity.Unit{entity.UnitIRT, entity.UnitUSD} {
+			steps, err := findPath(graph, t.Symbol, string(unit))
+			if err != nil {
+				continue
+			}
+			for _, st := range steps {
+				if st.Symbol != "" && !slices.Contains(symbols, st.Symbol) {
+					symbols = append(symbols, st.Symbol)
+				}
+			}
+		}
+		needed[t.Symbol] = symbols
+	}
+	return needed
+}
+
+// pricesAt returns the last price at or before at of the symbols that value positions.
+func (p *PortfolioUseCase) pricesAt(ctx context.Context, positions []position, needed map[string][]string, at time.Time) ([]entity.Price, error) {
+	var prices []entity.Price
+	seen := make(map[string]bool)
+	for _, pos := range positions {
+		for _, symbol := range needed[pos.symbol] {
+			if seen[symbol] {
+				continue
+			}
+			seen[symbol] = true
+			// PriceBefore excludes at itself; history times are whole seconds
+			price, err := p.prices.PriceBefore(ctx, symbol, at.Add(time.Second))
+			if errors.Is(err, entity.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, price)
+		}
+	}
+	return prices, nil
+}
+
+type position struct {
+	symbol   string
+	quantity float64
+	costIRT  float64
+	costUSD  float64
+}
+
+// buildPositions replays transactions executed up to until (all when zero) with the average cost method.
+// It returns the open positions in symbol order and the realized P&L of the sells.
+func buildPositions(txs []entity.PortfolioTransaction, until time.Time) ([]position, float64, float64) {
+	open := make(map[string]*position)
+	var realizedIRT, realizedUSD float64
+	for _, t := range sortedTransactions(txs) {
+		if !until.IsZero() && t.ExecutedAt.After(until) {
+			break
+		}
+		pos, ok := open[t.Symbol]
+		if !ok {
+			pos = &position{symbol: t.Symbol}
+			open[t.Symbol] = pos
+		}
+		if t.Side == entity.TransactionBuy {
+			pos.quantity += t.Quantity
+			pos.costIRT += t.Quantity * t.PriceIRT
+			pos.costUSD += t.Quantity * t.PriceUSD
+			continue
+		}
+		if pos.quantity <= quantityEpsilon {
+			continue
+		}
+		q := math.Min(t.Quantity, pos.quantity)
+		avgIRT, avgUSD := pos.costIRT/pos.quantity, pos.costUSD/pos.quantity
+		realizedIRT += q * (t.PriceIRT - avgIRT)
+		realizedUSD += q * (t.PriceUSD - avgUSD)
+		pos.quantity -= q
+		pos.costIRT -= q * avgIRT
+		pos.costUSD -= q * avgUSD
+	}
+
+	positions := make([]position, 0, len(open))
+	for _, pos := range open {
+		if pos.quantity > quantityEpsilon {
+			positions = append(positions, *pos)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].symbol < positions[j].symbol })
+	return positions, realizedIRT, realizedUSD
+}
+
+// checkHoldings rejects transaction sets where a sell exceeds the quantity held at that time.
+func checkHoldings(txs []entity.PortfolioTransaction) error {
+	held := make(map[string]float64)
+	for _, t := range sortedTransactions(txs) {
+		if t.Side == entity.TransactionBuy {
+			held[t.Symbol] += t.Quantity
+			continue
+		}
+		if t.Quantity > held[t.Symbol]+quantityEpsilon {
+			return fmt.Errorf("%w: selling %g %s on %s exceeds the %g held", ErrInvalidTransaction,
+				t.Quantity, t.Symbol, t.ExecutedAt.Format(time.RFC3339), held[t.Symbol])
+		}
+		held[t.Symbol] -= t.Quantity
+	}
+	return nil
+}
+
+func sortedTransactions(txs []entity.PortfolioTransaction) []entity.PortfolioTransaction {
+	sorted := slices.Clone(txs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ExecutedAt.Before(sorted[j].ExecutedAt) })
+	return sorted
+}
+
+// pathRate returns how many `to` one `from` is worth in the rate graph.
+func pathRate(graph map[string][]rateEdge, from, to string) (float64, bool) {
+	if from != to {
+		if _, ok := graph[from]; !ok {
+			return 0, false
+		}
+	}
+	steps, err := findPath(graph, from, to)
+	if err != nil {
+		return 0, false
+	}
+	rate := 1.0
+	for _, st := range steps {
+		rate *= st.Rate
+	}
+	return rate, true
+}
+
+func percentOf(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
@@ -4,41 +4,153 @@ package usecase
 import (
 	"context"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ar-mokhtari/market-tracker/entity"
 )
 
 type PriceUseCase struct {
-	repo          Repo
-	apiKey        string
-	baseURL       string
-	httpClient    *http.Client
-	OnUpdate      func([]entity.Price)
-	FetchInterval int
-}
-
-func NewPriceUseCase(repo Repo, apiKey string, baseURL string, interval int) *PriceUseCase {
-	return &PriceUseCase{
-		repo:          repo,
-		apiKey:        apiKey,
-		baseURL:       baseURL,
-		FetchInterval: interval,
+	repo       Repo
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+	OnUpdate   func(context.Context, []entity.Price)
+
+	fetchInterval   atomic.Int64 // time.Duration
+	intervalChanged chan struct{}
+
+	indicatorCache *indicatorCache
+
+	// The latest price of every symbol, see LatestPrices
+	quotes     quoteCache
+	quoteStore QuoteStore
+
+	// The data version served to HTTP caches, see DataVersion
+	updateSeq   atomic.Uint64
+	updatedAt   atomic.Int64 // unix nanoseconds
+	fetchedHash atomic.Uint64
+
+	breaker   *circuitBreaker
+	startedAt time.Time
+	fetchMu   sync.Mutex
+	lastFetch FetchStatus
+}
+
+// FetchStatus describes the recent health of the upstream price fetcher.
+type FetchStatus struct {
+	StartedAt   time.Time // When the use case was created, the reference before any success
+	LastAttempt time.Time
+	LastSuccess time.Time
+	LastError   string
+	Failures    int       // Consecutive failed cycles
+	Circuit     string    // CircuitClosed, CircuitOpen or CircuitHalfOpen
+	RetryAt     time.Time // When an open circuit lets the next cycle through
+}
+
+func NewPriceUseCase(repo Repo, apiKey string, baseURL string, interval time.Duration) *PriceUseCase {
+	uc := &PriceUseCase{
+		repo:    repo,
+		apiKey:  apiKey,
+		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 20 * time.Second,
 		},
+		intervalChanged: make(chan struct{}, 1),
+		indicatorCache:  newIndicatorCache(),
+		breaker:         newCircuitBreaker(circuitFailureThreshold, circuitCooldown),
+		startedAt:       time.Now(),
+	}
+	uc.fetchInterval.Store(int64(interval))
+	return uc
+}
+
+// FetchInterval returns the time between fetch cycles.
+func (uc *PriceUseCase) FetchInterval() time.Duration {
+	return time.Duration(uc.fetchInterval.Load())
+}
+
+// SetFetchInterval changes the time between fetch cycles; a running automation
+// picks it up after the current wait.
+func (uc *PriceUseCase) SetFetchInterval(d time.Duration) {
+	if d <= 0 || time.Duration(uc.fetchInterval.Swap(int64(d))) == d {
+		return
 	}
+	select {
+	case uc.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// FetchStatus returns the outcome of the recent fetch cycles and the provider circuit state.
+func (uc *PriceUseCase) FetchStatus() FetchStatus {
+	uc.fetchMu.Lock()
+	status := uc.lastFetch
+	uc.fetchMu.Unlock()
+	status.StartedAt = uc.startedAt
+	status.Circuit, status.Failures, status.RetryAt = uc.breaker.snapshot()
+	return status
 }
 
-func (uc *PriceUseCase) GetPrices(pType string) ([]entity.Price, error) {
-	return uc.repo.List(pType)
+func (uc *PriceUseCase) recordFetch(err error) {
+	now := time.Now()
+	uc.breaker.record(err, now)
+	uc.fetchMu.Lock()
+	defer uc.fetchMu.Unlock()
+	uc.lastFetch.LastAttempt = now
+	if err != nil {
+		uc.lastFetch.LastError = err.Error()
+		return
+	}
+	uc.lastFetch.LastSuccess = now
+	uc.lastFetch.LastError = ""
+}
+
+// GetPrices returns the latest prices of a type, or of every type when pType is empty.
+func (uc *PriceUseCase) GetPrices(ctx context.Context, pType string) ([]entity.Price, error) {
+	all, err := uc.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices := make([]entity.Price, 0, len(all))
+	for _, p := range all {
+		if pType == "" || p.Type == pType {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
 }
 
-func (uc *PriceUseCase) GetSymbolTimeline(symbol string) ([]entity.Price, error) {
+func (uc *PriceUseCase) GetSymbolTimeline(ctx context.Context, symbol string) ([]entity.Price, error) {
 	const defaultLimit = 24 // Last 24 records for hourly timeline
-	return uc.repo.GetHistory(symbol, defaultLimit)
+	return uc.repo.GetHistory(ctx, symbol, defaultLimit)
+}
+
+// GetSymbolHistory returns the recorded prices of a symbol within [from, to].
+func (uc *PriceUseCase) GetSymbolHistory(ctx context.Context, symbol string, from, to time.Time) ([]entity.Price, error) {
+	const maxRangeRecords = 5000
+	return uc.repo.GetHistoryRange(ctx, symbol, from, to, maxRangeRecords)
 }
 
-func (uc *PriceUseCase) ListPrices(ctx context.Context, priceType string) ([]entity.Price, error) {
-	return uc.repo.GetAllPrices(ctx, priceType)
+// GetQuotes returns the latest prices of the given symbols, in the requested order.
+// Unknown symbols are skipped.
+func (uc *PriceUseCase) GetQuotes(ctx context.Context, symbols []string) ([]entity.Price, error) {
+	all, err := uc.latestPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[string]entity.Price, len(all))
+	for _, p := range all {
+		bySymbol[p.Symbol] = p
+	}
+
+	quotes := make([]entity.Price, 0, len(symbols))
+	for _, s := range symbols {
+		if p, ok := bySymbol[strings.ToUpper(s)]; ok {
+			quotes = append(quotes, p)
+		}
+	}
+	return quotes, nil
 }
@@ -2,13 +2,110 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/ar-mokhtari/market-tracker/entity"
 )
 
 type Repo interface {
-	Upsert(p entity.Price) error
-	List(pType string) ([]entity.Price, error)
-	GetHistory(symbol string, limit int) ([]entity.Price, error)
+	Upsert(ctx context.Context, p entity.Price) error
+	List(ctx context.Context, pType string) ([]entity.Price, error)
+	GetHistory(ctx context.Context, symbol string, limit int) ([]entity.Price, error)
+	GetHistoryRange(ctx context.Context, symbol string, from, to time.Time, limit int) ([]entity.Price, error)
+	PriceBefore(ctx context.Context, symbol string, at time.Time) (entity.Price, error)
 	GetAllPrices(ctx context.Context, priceType string) ([]entity.Price, error)
+	ListLatest(ctx context.Context) ([]entity.Price, error)
+	GetPricesAt(ctx context.Context, at time.Time) ([]entity.Price, error)
+	HistoryTimes(ctx context.Context, symbol string) ([]int64, error)
+	InsertHistory(ctx context.Context, prices []entity.Price) error
+	SetLatest(ctx context.Context, p entity.Price) error
+	HistoryPage(ctx context.Context, symbol string, from, to time.Time, afterTime int64, afterID uint, limit int) ([]entity.Price, error)
+}
+
+// QuoteStore is an external cache of the latest prices shared between instances.
+// LoadQuotes returns nil without an error when the store holds no quotes.
+type QuoteStore interface {
+	LoadQuotes(ctx context.Context) ([]entity.Price, error)
+	SaveQuotes(ctx context.Context, prices []entity.Price, ttl time.Duration) error
+}
+
+type AlertRepo interface {
+	CreateAlert(ctx context.Context, a *entity.Alert) error
+	GetAlert(ctx context.Context, id uint) (entity.Alert, error)
+	ListAlerts(ctx context.Context, activeOnly bool) ([]entity.Alert, error)
+	ListUserAlerts(ctx context.Context, userID uint) ([]entity.Alert, error)
+	DeleteAlert(ctx context.Context, id uint) error
+	MarkAlertTriggered(ctx context.Context, id uint, at time.Time, deactivate bool) error
+	SaveAlertEvent(ctx context.Context, e *entity.AlertEvent) error
+	ListAlertEvents(ctx context.Context, alertID uint, limit int) ([]entity.AlertEvent, error)
+}
+
+// Notifier delivers triggered alerts to a channel such as the websocket hub.
+type Notifier interface {
+	Notify(ctx context.Context, event entity.AlertEvent) error
+}
+
+// NotifierFunc adapts a plain function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, event entity.AlertEvent) error
+
+func (f NotifierFunc) Notify(ctx context.Context, event entity.AlertEvent) error {
+	return f(ctx, event)
+}
+
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, wh *entity.Webhook) error
+	GetWebhook(ctx context.Context, id uint) (entity.Webhook, error)
+	ListWebhooks(ctx context.Context, activeOnly bool) ([]entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+	EnqueueDelivery(ctx context.Context, d *entity.WebhookDelivery) error
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID uint, status string, limit int) ([]entity.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id uint) (entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d entity.WebhookDelivery) error
+}
+
+type SubscriptionRepo interface {
+	SaveChatSubscription(ctx context.Context, s entity.ChatSubscription) error
+	GetChatSubscription(ctx context.Context, chatID int64) (entity.ChatSubscription, error)
+	DeleteChatSubscription(ctx context.Context, chatID int64) error
+	ListChatSubscriptions(ctx context.Context) ([]entity.ChatSubscription, error)
+}
+
+type APIKeyRepo interface {
+	CreateAPIKey(ctx context.Context, k *entity.APIKey) error
+	GetAPIKey(ctx context.Context, id uint) (entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uint) error
+	AddAPIKeyUsage(ctx context.Context, id uint, day time.Time, n int64) error
+	GetAPIKeyUsage(ctx context.Context, id uint, day time.Time) (int64, error)
+	ListAPIKeyUsage(ctx context.Context, id uint, days int) ([]entity.APIKeyUsage, error)
+}
+
+type UserRepo interface {
+	CreateUser(ctx context.Context, u *entity.User) error
+	GetUser(ctx context.Context, id uint) (entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (entity.User, error)
+	CreateRefreshToken(ctx context.Context, t *entity.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, id uint) error
+	RevokeUserRefreshTokens(ctx context.Context, userID uint) error
+}
+
+type WatchlistRepo interface {
+	CreateWatchlist(ctx context.Context, wl *entity.Watchlist) error
+	GetWatchlist(ctx context.Context, id uint) (entity.Watchlist, error)
+	ListWatchlists(ctx context.Context, userID uint) ([]entity.Watchlist, error)
+	UpdateWatchlist(ctx context.Context, wl *entity.Watchlist) error
+	DeleteWatchlist(ctx context.Context, id uint) error
+}
+
+type PortfolioRepo interface {
+	CreatePortfolio(ctx context.Context, p *entity.Portfolio) error
+	GetPortfolio(ctx context.Context, id uint) (entity.Portfolio, error)
+	ListPortfolios(ctx context.Context, userID uint) ([]entity.Portfolio, error)
+	DeletePortfolio(ctx context.Context, id uint) error
+	CreateTransaction(ctx context.Context, t *entity.PortfolioTransaction) error
+	ListTransactions(ctx context.Context, portfolioID uint) ([]entity.PortfolioTransaction, error)
+	DeleteTransaction(ctx context.Context, portfolioID, id uint) error
 }
@@ -0,0 +1,119 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// quoteTTLCycles is how many fetch intervals cached quotes stay valid without a refresh,
+// so instances that do not fetch themselves still pick up the database changes.
+const quoteTTLCycles = 2
+
+// quoteCache holds the latest price of every symbol. The fetch pipeline and imports
+// replace it after storing prices, so reads between cycles never reach the database.
+type quoteCache struct {
+	mu      sync.RWMutex
+	prices  []entity.Price // sorted by symbol
+	expires time.Time
+
+	loadMu sync.Mutex // serializes misses so concurrent readers share one load
+}
+
+func (c *quoteCache) get() ([]entity.Price, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.prices == nil || time.Now().After(c.expires) {
+		return nil, false
+	}
+	return c.prices, true
+}
+
+func (c *quoteCache) set(prices []entity.Price, ttl time.Duration) {
+	if prices == nil {
+		prices = []entity.Price{}
+	}
+	c.mu.Lock()
+	c.prices, c.expires = prices, time.Now().Add(ttl)
+	c.mu.Unlock()
+}
+
+// SetQuoteStore shares the latest quotes through an external cache. Misses of the
+// in-memory cache are served from the store before the database, and every refresh
+// writes the store, so instances behind a load balancer see the same prices.
+func (uc *PriceUseCase) SetQuoteStore(store QuoteStore) {
+	uc.quoteStore = store
+}
+
+// LatestPrices returns the latest price of every symbol, sorted by symbol, from memory.
+// The returned slice belongs to the caller.
+func (uc *PriceUseCase) LatestPrices(ctx context.Context) ([]entity.Price, error) {
+	prices, err := uc.latestPrices(ctx)
+	return slices.Clone(prices), err
+}
+
+// latestPrices is LatestPrices without the copy; callers must not modify the result.
+func (uc *PriceUseCase) latestPrices(ctx context.Context) ([]entity.Price, error) {
+	if prices, ok := uc.quotes.get(); ok {
+		return prices, nil
+	}
+	uc.quotes.loadMu.Lock()
+	defer uc.quotes.loadMu.Unlock()
+	if prices, ok := uc.quotes.get(); ok {
+		return prices, nil
+	}
+
+	if uc.quoteStore != nil {
+		prices, err := uc.quoteStore.LoadQuotes(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "quote store read failed", "err", err)
+		} else if prices != nil {
+			uc.quotes.set(prices, uc.quoteTTL())
+			return prices, nil
+		}
+	}
+	return uc.loadQuotes(ctx)
+}
+
+// WarmQuotes fills the quote cache at startup so the first requests are served from memory.
+func (uc *PriceUseCase) WarmQuotes(ctx context.Context) error {
+	_, err := uc.latestPrices(ctx)
+	return err
+}
+
+// refreshQuotes reloads the cache after prices were stored. Failures are logged; the
+// previous quotes are served until they expire.
+func (uc *PriceUseCase) refreshQuotes(ctx context.Context) {
+	uc.quotes.loadMu.Lock()
+	defer uc.quotes.loadMu.Unlock()
+	if _, err := uc.loadQuotes(ctx); err != nil {
+		slog.WarnContext(ctx, "quote cache refresh failed", "err", err)
+	}
+}
+
+// loadQuotes reads the latest prices from the database into memory and the store.
+// The caller holds loadMu.
+func (uc *PriceUseCase) loadQuotes(ctx context.Context) ([]entity.Price, error) {
+	prices, err := uc.repo.ListLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ttl := uc.quoteTTL()
+	uc.quotes.set(prices, ttl)
+	if uc.quoteStore != nil {
+		if err := uc.quoteStore.SaveQuotes(ctx, prices, ttl); err != nil {
+			slog.WarnContext(ctx, "quote store write failed", "err", err)
+		}
+	}
+	slog.DebugContext(ctx, "quote cache loaded", "symbols", len(prices))
+	return uc.quotes.prices, nil
+}
+
+func (uc *PriceUseCase) quoteTTL() time.Duration {
+	return quoteTTLCycles * max(uc.FetchInterval(), time.Minute)
+}
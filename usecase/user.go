@@ -0,0 +1,183 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidUser        = errors.New("invalid user")
+	ErrEmailTaken         = errors.New("email is already registered")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+const (
+	minPasswordLength = 8
+	refreshTokenTTL   = 30 * 24 * time.Hour
+	tokenIssuer       = "market-tracker"
+)
+
+// TokenPair is returned on login and refresh. Access tokens are JWTs signed with AUTH_KEY.
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+type UserUseCase struct {
+	repo      UserRepo
+	key       []byte
+	accessTTL time.Duration
+}
+
+func NewUserUseCase(repo UserRepo, key string, accessTTL time.Duration) *UserUseCase {
+	return &UserUseCase{repo: repo, key: []byte(key), accessTTL: accessTTL}
+}
+
+// Register creates a user with a bcrypt password hash.
+func (u *UserUseCase) Register(ctx context.Context, email, password, name string) (entity.User, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return entity.User{}, fmt.Errorf("%w: invalid email", ErrInvalidUser)
+	}
+	if len(password) < minPasswordLength {
+		return entity.User{}, fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUser, minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return entity.User{}, fmt.Errorf("%w: %v", ErrInvalidUser, err)
+	}
+
+	user := entity.User{
+		Email:        strings.ToLower(addr.Address),
+		Name:         strings.TrimSpace(name),
+		PasswordHash: string(hash),
+	}
+	if err := u.repo.CreateUser(ctx, &user); err != nil {
+		if errors.Is(err, entity.ErrAlreadyExists) {
+			return entity.User{}, ErrEmailTaken
+		}
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// Login checks the password and issues a new token pair.
+func (u *UserUseCase) Login(ctx context.Context, email, password string) (TokenPair, error) {
+	user, err := u.repo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, entity.ErrNotFound) {
+		return TokenPair{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return TokenPair{}, ErrInvalidCredentials
+	}
+	return u.issue(ctx, user.ID)
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token works once;
+// presenting a used one again revokes every session of the user.
+func (u *UserUseCase) Refresh(ctx context.Context, raw string) (TokenPair, error) {
+	t, err := u.repo.GetRefreshToken(ctx, hashSecret(raw))
+	if errors.Is(err, entity.ErrNotFound) {
+		return TokenPair{}, ErrUnauthorized
+	}
+	if err != nil {
+		return TokenPair{}, err
+	}
+	if t.RevokedAt != nil {
+		if err := u.repo.RevokeUserRefreshTokens(ctx, t.UserID); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, ErrUnauthorized
+	}
+	if time.Now().After(t.ExpiresAt) {
+		return TokenPair{}, ErrUnauthorized
+	}
+	if err := u.repo.RevokeRefreshToken(ctx, t.ID); err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return TokenPair{}, ErrUnauthorized
+		}
+		return TokenPair{}, err
+	}
+	return u.issue(ctx, t.UserID)
+}
+
+// Logout revokes a refresh token. Access tokens stay valid until they expire.
+func (u *UserUseCase) Logout(ctx context.Context, raw string) error {
+	t, err := u.repo.GetRefreshToken(ctx, hashSecret(raw))
+	if errors.Is(err, entity.ErrNotFound) {
+		return ErrUnauthorized
+	}
+	if err != nil {
+		return err
+	}
+	if err := u.repo.RevokeRefreshToken(ctx, t.ID); err != nil && !errors.Is(err, entity.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (u *UserUseCase) Get(ctx context.Context, id uint) (entity.User, error) {
+	return u.repo.GetUser(ctx, id)
+}
+
+// ParseToken validates an access token and returns the user ID it was issued to.
+func (u *UserUseCase) ParseToken(raw string) (uint, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(*jwt.Token) (interface{}, error) {
+		return u.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(tokenIssuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, ErrUnauthorized
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrUnauthorized
+	}
+	return uint(id), nil
+}
+
+func (u *UserUseCase) issue(ctx context.Context, userID uint) (TokenPair, error) {
+	now := time.Now()
+	expires := now.Add(u.accessTTL)
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    tokenIssuer,
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expires),
+	}).SignedString(u.key)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return TokenPair{}, err
+	}
+	refresh := hex.EncodeToString(secret)
+	if err := u.repo.CreateRefreshToken(ctx, &entity.RefreshToken{
+		UserID:    userID,
+		Hash:      hashSecret(refresh),
+		ExpiresAt: now.Add(refreshTokenTTL),
+	}); err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{AccessToken: access, TokenType: "Bearer", ExpiresAt: expires, RefreshToken: refresh}, nil
+}
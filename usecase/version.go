@@ -0,0 +1,59 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// DataVersion identifies the current state of the stored prices for HTTP caching. The
+// tag changes whenever a fetch cycle stores prices that differ from the previous cycle
+// or an import adds data, and on every restart; modified is when that last happened.
+func (uc *PriceUseCase) DataVersion() (tag string, modified time.Time) {
+	seq := uc.updateSeq.Load()
+	modified = uc.startedAt
+	if at := uc.updatedAt.Load(); at != 0 {
+		modified = time.Unix(0, at)
+	}
+	return fmt.Sprintf("%x-%x", uc.startedAt.UnixNano(), seq), modified
+}
+
+// NextFetchIn returns how long the current prices are expected to stay unchanged: the time
+// left until the next fetch cycle is due, or zero when it is due already.
+func (uc *PriceUseCase) NextFetchIn() time.Duration {
+	uc.fetchMu.Lock()
+	last := uc.lastFetch.LastAttempt
+	uc.fetchMu.Unlock()
+	if last.IsZero() {
+		return 0
+	}
+	return max(time.Until(last.Add(uc.FetchInterval())), 0)
+}
+
+// markUpdated starts a new data version.
+func (uc *PriceUseCase) markUpdated() {
+	uc.updatedAt.Store(time.Now().UnixNano())
+	uc.updateSeq.Add(1)
+}
+
+// observeFetched starts a new data version when the fetched prices differ from the
+// previous cycle. The hash does not depend on the order of the prices.
+func (uc *PriceUseCase) observeFetched(prices []entity.Price) {
+	var sum uint64
+	for _, p := range prices {
+		h := fnv.New64a()
+		for _, s := range []string{p.Symbol, p.Type, p.Unit, p.Price.String(), p.ChangeValue.String(),
+			strconv.FormatFloat(p.ChangePercent, 'f', -1, 64), strconv.FormatInt(p.TimeUnix, 10), p.Date, p.Time, p.NameEn, p.NameFa} {
+			h.Write([]byte(s))
+			h.Write([]byte{0})
+		}
+		sum += h.Sum64()
+	}
+	if uc.fetchedHash.Swap(sum) != sum {
+		uc.markUpdated()
+	}
+}
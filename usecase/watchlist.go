@@ -0,0 +1,138 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var ErrInvalidWatchlist = errors.New("invalid watchlist")
+
+const maxWatchlistSymbols = 100
+
+// WatchlistQuotes holds the latest prices of a watchlist, in the watchlist's symbol order.
+type WatchlistQuotes struct {
+	Watchlist entity.Watchlist
+	Quotes    []entity.Price
+	Missing   []string // Symbols without a stored price
+}
+
+type WatchlistUseCase struct {
+	repo   WatchlistRepo
+	prices *PriceUseCase
+}
+
+func NewWatchlistUseCase(repo WatchlistRepo, prices *PriceUseCase) *WatchlistUseCase {
+	return &WatchlistUseCase{repo: repo, prices: prices}
+}
+
+func (w *WatchlistUseCase) Create(ctx context.Context, wl *entity.Watchlist) error {
+	if err := normalizeWatchlist(wl); err != nil {
+		return err
+	}
+	if err := w.repo.CreateWatchlist(ctx, wl); err != nil {
+		return watchlistError(err, wl.Name)
+	}
+	return nil
+}
+
+// Get returns a watchlist of the user. Other users' watchlists are reported as not found.
+func (w *WatchlistUseCase) Get(ctx context.Context, id, userID uint) (entity.Watchlist, error) {
+	wl, err := w.repo.GetWatchlist(ctx, id)
+	if err == nil && wl.UserID != userID {
+		return entity.Watchlist{}, entity.ErrNotFound
+	}
+	return wl, err
+}
+
+func (w *WatchlistUseCase) List(ctx context.Context, userID uint) ([]entity.Watchlist, error) {
+	return w.repo.ListWatchlists(ctx, userID)
+}
+
+// Update replaces the name and symbols of a watchlist.
+func (w *WatchlistUseCase) Update(ctx context.Context, wl *entity.Watchlist) error {
+	current, err := w.Get(ctx, wl.ID, wl.UserID)
+	if err != nil {
+		return err
+	}
+	if err := normalizeWatchlist(wl); err != nil {
+		return err
+	}
+	wl.CreatedAt = current.CreatedAt
+	if err := w.repo.UpdateWatchlist(ctx, wl); err != nil {
+		return watchlistError(err, wl.Name)
+	}
+	return nil
+}
+
+func (w *WatchlistUseCase) Delete(ctx context.Context, id, userID uint) error {
+	if _, err := w.Get(ctx, id, userID); err != nil {
+		return err
+	}
+	return w.repo.DeleteWatchlist(ctx, id)
+}
+
+// Symbols returns the symbols of a user's watchlist, for stream subscriptions.
+func (w *WatchlistUseCase) Symbols(ctx context.Context, id, userID uint) ([]string, error) {
+	wl, err := w.Get(ctx, id, userID)
+	return wl.Symbols, err
+}
+
+// Quotes returns the latest prices of a watchlist, optionally converted to unit.
+func (w *WatchlistUseCase) Quotes(ctx context.Context, id, userID uint, unit entity.Unit) (WatchlistQuotes, error) {
+	wl, err := w.Get(ctx, id, userID)
+	if err != nil {
+		return WatchlistQuotes{}, err
+	}
+	quotes, err := w.prices.GetQuotes(ctx, wl.Symbols)
+	if err != nil {
+		return WatchlistQuotes{}, err
+	}
+	if unit != "" {
+		if quotes, err = w.prices.ConvertUnits(ctx, quotes, unit); err != nil {
+			return WatchlistQuotes{}, err
+		}
+	}
+
+	result := WatchlistQuotes{Watchlist: wl, Quotes: quotes, Missing: []string{}}
+	for _, s := range wl.Symbols {
+		if !slices.ContainsFunc(quotes, func(p entity.Price) bool { return p.Symbol == s }) {
+			result.Missing = append(result.Missing, s)
+		}
+	}
+	return result, nil
+}
+
+func normalizeWatchlist(wl *entity.Watchlist) error {
+	wl.Name = strings.TrimSpace(wl.Name)
+	if wl.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWatchlist)
+	}
+	symbols := make([]string, 0, len(wl.Symbols))
+	for _, s := range wl.Symbols {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s == "" || strings.Contains(s, ",") {
+			return fmt.Errorf("%w: invalid symbol %q", ErrInvalidWatchlist, s)
+		}
+		if !slices.Contains(symbols, s) {
+			symbols = append(symbols, s)
+		}
+	}
+	if len(symbols) > maxWatchlistSymbols {
+		return fmt.Errorf("%w: at most %d symbols", ErrInvalidWatchlist, maxWatchlistSymbols)
+	}
+	wl.Symbols = symbols
+	return nil
+}
+
+func watchlistError(err error, name string) error {
+	if errors.Is(err, entity.ErrAlreadyExists) {
+		return fmt.Errorf("%w: a watchlist named %q", entity.ErrAlreadyExists, name)
+	}
+	return err
+}
@@ -0,0 +1,383 @@
+// Package usecase contains the business logic for price processing.
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+const (
+	webhookMaxAttempts  = 8
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	webhookPollInterval = 2 * time.Second
+	webhookBatchSize    = 50
+	maxDeliveriesPerLog = 200
+)
+
+// Headers sent with every webhook POST. The signature is the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the webhook secret.
+const (
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// WebhookPayload is the JSON body POSTed to webhooks.
+type WebhookPayload struct {
+	Event      string      `json:"event"`
+	OccurredAt int64       `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+type WebhookUseCase struct {
+	repo         WebhookRepo
+	httpClient   *http.Client
+	allowPrivate bool // Webhooks may reach loopback and private addresses, e.g. a local receiver
+
+	mu         sync.Mutex
+	lastPrices map[string]string // Last seen price per symbol, to detect changes
+}
+
+// NewWebhookUseCase only lets webhooks reach public addresses unless allowPrivate is set.
+func NewWebhookUseCase(repo WebhookRepo, allowPrivate bool) *WebhookUseCase {
+	// Addresses are checked again when connecting, as DNS may change after registration
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = dialPublicOnly
+	}
+	return &WebhookUseCase{
+		repo:         repo,
+		allowPrivate: allowPrivate,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		},
+	}
+}
+
+// Create validates a webhook and generates a secret when none is given.
+func (w *WebhookUseCase) Create(ctx context.Context, wh *entity.Webhook) error {
+	u, err := url.Parse(wh.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if !w.allowPrivate {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+		}
+	}
+	for _, e := range wh.Events {
+		if e != entity.EventPriceChanged && e != entity.EventAlertTriggered {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+	}
+	for i, s := range wh.Symbols {
+		wh.Symbols[i] = strings.ToUpper(strings.TrimSpace(s))
+	}
+	if wh.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		wh.Secret = hex.EncodeToString(secret)
+	}
+	wh.Active = true
+	return w.repo.CreateWebhook(ctx, wh)
+}
+
+// List returns registered webhooks without their secrets.
+func (w *WebhookUseCase) List(ctx context.Context) ([]entity.Webhook, error) {
+	hooks, err := w.repo.ListWebhooks(ctx, false)
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, err
+}
+
+func (w *WebhookUseCase) Get(ctx context.Context, id uint) (entity.Webhook, error) {
+	wh, err := w.repo.GetWebhook(ctx, id)
+	wh.Secret = ""
+	return wh, err
+}
+
+func (w *WebhookUseCase) Delete(ctx context.Context, id uint) error {
+	return w.repo.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the delivery log of a webhook, e.g. status "dead" for the dead letters.
+func (w *WebhookUseCase) Deliveries(ctx context.Context, webhookID uint, status string) ([]entity.WebhookDelivery, error) {
+	if _, err := w.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return w.repo.ListDeliveries(ctx, webhookID, status, maxDeliveriesPerLog)
+}
+
+// Redeliver puts a delivered or dead delivery back in the queue.
+func (w *WebhookUseCase) Redeliver(ctx context.Context, id uint) (entity.WebhookDelivery, error) {
+	d, err := w.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return d, err
+	}
+	d.Status = entity.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+	d.LastError = ""
+	return d, w.repo.UpdateDelivery(ctx, d)
+}
+
+// PricesChanged enqueues price.changed deliveries for prices that differ from the previous cycle.
+// The first cycle after startup only records prices.
+func (w *WebhookUseCase) PricesChanged(ctx context.Context, prices []entity.Price) {
+	w.mu.Lock()
+	first := w.lastPrices == nil
+	if first {
+		w.lastPrices = make(map[string]string, len(prices))
+	}
+	var changed []entity.Price
+	for _, p := range prices {
+		key := p.Type + "|" + p.Symbol
+		if prev, ok := w.lastPrices[key]; (!ok || prev != p.Price.String()) && !first {
+			changed = append(changed, p)
+		}
+		w.lastPrices[key] = p.Price.String()
+	}
+	w.mu.Unlock()
+
+	if len(changed) == 0 {
+		return
+	}
+
+	hooks, err := w.repo.ListWebhooks(ctx, true)
+	if err != nil {
+		slog.ErrorContext(ctx, "webhook list failed", "err", err)
+		return
+	}
+	for _, wh := range hooks {
+		if !matchesList(wh.Events, entity.EventPriceChanged) {
+			continue
+		}
+		var matched []entity.Price
+		for _, p := range changed {
+			if matchesList(wh.Symbols, p.Symbol) && matchesList(wh.Types, p.Type) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) > 0 {
+			w.enqueue(ctx, wh, entity.EventPriceChanged, matched)
+		}
+	}
+}
+
+// Notify enqueues alert.triggered deliveries, so webhooks plug into the alert engine as a Notifier.
+func (w *WebhookUseCase) Notify(ctx context.Context, event entity.AlertEvent) error {
+	hooks, err := w.repo.ListWebhooks(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, wh := range hooks {
+		if !matchesList(wh.Events, entity.EventAlertTriggered) || !matchesList(wh.Symbols, event.Symbol) {
+			continue
+		}
+		if len(wh.AlertIDs) > 0 && !slices.Contains(wh.AlertIDs, event.AlertID) {
+			continue
+		}
+		w.enqueue(ctx, wh, entity.EventAlertTriggered, event)
+	}
+	return nil
+}
+
+func (w *WebhookUseCase) enqueue(ctx context.Context, wh entity.Webhook, event string, data interface{}) {
+	body, err := json.Marshal(WebhookPayload{Event: event, OccurredAt: time.Now().Unix(), Data: data})
+	if err != nil {
+		slog.ErrorContext(ctx, "webhook payload failed", "webhook_id", wh.ID, "err", err)
+		return
+	}
+	d := entity.WebhookDelivery{
+		WebhookID:     wh.ID,
+		Event:         event,
+		Payload:       string(body),
+		Status:        entity.DeliveryPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := w.repo.EnqueueDelivery(ctx, &d); err != nil {
+		slog.ErrorContext(ctx, "webhook enqueue failed", "webhook_id", wh.ID, "err", err)
+	}
+}
+
+// StartDelivery processes the delivery queue until ctx is cancelled.
+func (w *WebhookUseCase) StartDelivery(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.deliverDue(ctx)
+		}
+	}
+}
+
+func (w *WebhookUseCase) deliverDue(ctx context.Context) {
+	due, err := w.repo.DueDeliveries(ctx, time.Now(), webhookBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "webhook queue read failed", "err", err)
+		return
+	}
+
+	hooks := make(map[uint]*entity.Webhook)
+	for _, d := range due {
+		wh, ok := hooks[d.WebhookID]
+		if !ok {
+			found, err := w.repo.GetWebhook(ctx, d.WebhookID)
+			if err != nil && !errors.Is(err, entity.ErrNotFound) {
+				slog.ErrorContext(ctx, "webhook load failed", "webhook_id", d.WebhookID, "err", err)
+				continue
+			}
+			if err == nil {
+				wh = &found
+			}
+			hooks[d.WebhookID] = wh
+		}
+
+		if wh == nil || !wh.Active {
+			d.Status = entity.DeliveryDead
+			d.LastError = "webhook removed or inactive"
+		} else {
+			w.attempt(ctx, *wh, &d)
+		}
+		if err := w.repo.UpdateDelivery(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "webhook delivery update failed", "delivery_id", d.ID, "err", err)
+		}
+	}
+}
+
+// attempt POSTs a delivery once and schedules the retry or dead-letters it.
+func (w *WebhookUseCase) attempt(ctx context.Context, wh entity.Webhook, d *entity.WebhookDelivery) {
+	d.Attempts++
+	code, err := w.post(ctx, wh, *d)
+	d.ResponseCode = code
+	if err == nil {
+		now := time.Now()
+		d.Status = entity.DeliveryDelivered
+		d.DeliveredAt = &now
+		d.LastError = ""
+		return
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= webhookMaxAttempts {
+		d.Status = entity.DeliveryDead
+		return
+	}
+	d.NextAttemptAt = time.Now().Add(retryBackoff(d.Attempts))
+}
+
+func (w *WebhookUseCase) post(ctx context.Context, wh entity.Webhook, d entity.WebhookDelivery) (int, error) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewBufferString(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "market-tracker-webhook")
+	req.Header.Set(HeaderWebhookEvent, d.Event)
+	req.Header.Set(HeaderWebhookDelivery, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(HeaderWebhookTimestamp, ts)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+SignWebhook(wh.Secret, ts, []byte(d.Payload)))
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("network level error: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", for senders and receivers alike.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryBackoff doubles the wait after every failed attempt, up to webhookMaxBackoff.
+func retryBackoff(attempts int) time.Duration {
+	d := webhookBaseBackoff << (attempts - 1)
+	if d <= 0 || d > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return d
+}
+
+// matchesList reports whether value passes a filter, where an empty filter matches everything.
+func matchesList(filter []string, value string) bool {
+	return len(filter) == 0 || slices.Contains(filter, value)
+}
+
+// checkPublicHost resolves a webhook host and rejects it when any of its addresses is
+// loopback, link-local, private or otherwise not reachable on the internet, so webhooks
+// cannot reach the server's own network.
+func checkPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("url host %s cannot be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return fmt.Errorf("url host %s resolves to the non-public address %s", host, addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// dialPublicOnly is a net.Dialer Control function that refuses connections to
+// non-public addresses, including ones a redirect or a DNS change leads to.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", addrPort.Addr().Unmap())
+	}
+	return nil
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ar-mokhtari/market-tracker/entity"
+)
+
+// memWebhookRepo keeps webhooks and deliveries in memory.
+type memWebhookRepo struct {
+	hooks      []entity.Webhook
+	deliveries []entity.WebhookDelivery
+}
+
+func (m *memWebhookRepo) CreateWebhook(_ context.Context, wh *entity.Webhook) error {
+	wh.ID = uint(len(m.hooks) + 1)
+	m.hooks = append(m.hooks, *wh)
+	return nil
+}
+
+func (m *memWebhookRepo) GetWebhook(_ context.Context, id uint) (entity.Webhook, error) {
+	for _, wh := range m.hooks {
+		if wh.ID == id {
+			return wh, nil
+		}
+	}
+	return entity.Webhook{}, entity.ErrNotFound
+}
+
+func (m *memWebhookRepo) ListWebhooks(context.Context, bool) ([]entity.Webhook, error) {
+	return m.hooks, nil
+}
+
+func (m *memWebhookRepo) DeleteWebhook(context.Context, uint) error { return nil }
+
+func (m *memWebhookRepo) EnqueueDelivery(_ context.Context, d *entity.WebhookDelivery) error {
+	d.ID = uint(len(m.deliveries) + 1)
+	m.deliveries = append(m.deliveries, *d)
+	return nil
+}
+
+func (m *memWebhookRepo) DueDeliveries(_ context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	var due []entity.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status == entity.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (m *memWebhookRepo) ListDeliveries(context.Context, uint, string, int) ([]entity.WebhookDelivery, error) {
+	return m.deliveries, nil
+}
+
+func (m *memWebhookRepo) GetDelivery(_ context.Context, id uint) (entity.WebhookDelivery, error) {
+	return m.deliveries[id-1], nil
+}
+
+func (m *memWebhookRepo) UpdateDelivery(_ context.Context, d entity.WebhookDelivery) error {
+	m.deliveries[d.ID-1] = d
+	return nil
+}
+
+// retryNow makes the queued retries due.
+func (m *memWebhookRepo) retryNow() {
+	for i := range m.deliveries {
+		m.deliveries[i].NextAttemptAt = time.Now().Add(-time.Second)
+	}
+}
+
+// receiver is a webhook endpoint that answers with the queued status codes, then 200.
+type receiver struct {
+	t      *testing.T
+	secret string
+
+	mu       sync.Mutex
+	statuses []int
+	requests int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	want := "sha256=" + SignWebhook(rc.secret, r.Header.Get(HeaderWebhookTimestamp), body)
+	if got := r.Header.Get(HeaderWebhookSignature); got != want {
+		rc.t.Errorf("signature = %q, want %q", got, want)
+	}
+	if got := r.Header.Get(HeaderWebhookEvent); got != entity.EventAlertTriggered {
+		rc.t.Errorf("event = %q, want %q", got, entity.EventAlertTriggered)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// newWebhookTest registers a webhook for a local receiver and queues one alert delivery.
+func newWebhookTest(t *testing.T, statuses ...int) (*WebhookUseCase, *memWebhookRepo, *receiver) {
+	t.Helper()
+	rc := &receiver{t: t, secret: "test-secret", statuses: statuses}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	repo := &memWebhookRepo{}
+	w := NewWebhookUseCase(repo, true)
+	ctx := context.Background()
+	if err := w.Create(ctx, &entity.Webhook{URL: srv.URL, Secret: rc.secret, Events: []string{entity.EventAlertTriggered}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(ctx, entity.AlertEvent{AlertID: 1, Symbol: "USD", Message: "USD above 100000"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("%d deliveries queued, want 1", len(repo.deliveries))
+	}
+	return w, repo, rc
+}
+
+func TestWebhookRejectsPrivateAddresses(t *testing.T) {
+	w := NewWebhookUseCase(&memWebhookRepo{}, false)
+	err := w.Create(context.Background(), &entity.Webhook{URL: "http://127.0.0.1:8080/hook"})
+	if err == nil {
+		t.Fatal("webhook to a loopback address was accepted")
+	}
+}
+
+func TestWebhookRetriesAfterServerError(t *testing.T) {
+	w, repo, rc := newWebhookTest(t, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	before := time.Now()
+	w.deliverDue(ctx)
+	d := repo.deliveries[0]
+	if d.Status != entity.DeliveryPending || d.Attempts != 1 || d.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after a 500: status %s, attempts %d, code %d", d.Status, d.Attempts, d.ResponseCode)
+	}
+	if wait := d.NextAttemptAt.Sub(before); wait < webhookBaseBackoff || wait > webhookBaseBackoff+time.Minute {
+		t.Errorf("retry scheduled after %s, want %s", wait, webhookBaseBackoff)
+	}
+
+	// Not due yet
+	w.deliverDue(ctx)
+	if rc.requests != 1 {
+		t.Fatalf("%d requests before the retry was due, want 1", rc.requests)
+	}
+
+	repo.retryNow()
+	w.deliverDue(ctx)
+	d = repo.deliveries[0]
+	if d.Status != entity.DeliveryDelivered || d.Attempts != 2 || d.DeliveredAt == nil {
+		t.Fatalf("after the retry: status %s, attempts %d", d.Status, d.Attempts)
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	statuses := make([]int, webhookMaxAttempts+1)
+	for i := range statuses {
+		statuses[i] = http.StatusInternalServerError
+	}
+	w, repo, rc := newWebhookTest(t, statuses...)
+
+	for i := 0; i < webhookMaxAttempts+2; i++ {
+		repo.retryNow()
+		w.deliverDue(context.Background())
+	}
+	d := repo.deliveries[0]
+	if d.Status != entity.DeliveryDead || d.Attempts != webhookMaxAttempts || d.LastError == "" {
+		t.Fatalf("status %s, attempts %d, last error %q", d.Status, d.Attempts, d.LastError)
+	}
+	if rc.requests != webhookMaxAttempts {
+		t.Errorf("%d requests, want %d", rc.requests, webhookMaxAttempts)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookMaxBackoff},
+		{70, webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/ar-mokhtari/market-tracker/entity"
 )
@@ -13,5 +14,8 @@ func ValidatePrice(p entity.Price) error {
 	if p.Price == "" || p.Price == "0" {
 		return errors.New("invalid price value")
 	}
+	if v, err := strconv.ParseFloat(p.Price.String(), 64); err != nil || v <= 0 {
+		return errors.New("price must be a positive number")
+	}
 	return nil
 }